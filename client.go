@@ -1,14 +1,18 @@
 package tinysearch
 
 import (
+	"bytes"
+	genJson "encoding/json"
 	"errors"
 	"fmt"
+	"github.com/andyzhou/tinysearch/define"
 	"github.com/andyzhou/tinysearch/iface"
 	"github.com/andyzhou/tinysearch/json"
 	"github.com/andyzhou/tinysearch/lib"
 	"github.com/andyzhou/tinysearch/rpc"
 	"log"
 	"sync"
+	"time"
 )
 
 /*
@@ -23,6 +27,12 @@ const (
 	QueryOptKindOfGen = iota
 	QueryOptKindOfAgg
 	QueryOptKindOfSuggest
+	QueryOptKindOfDelete
+	QueryOptKindOfGetSynonym
+	QueryOptKindOfSetSynonym
+	QueryOptKindOfAddPercolate
+	QueryOptKindOfRemovePercolate
+	QueryOptKindOfGetPercolate
 )
 
 const (
@@ -284,11 +294,64 @@ func (f *Client) DocRemove(
 	return err
 }
 
+//remove doc by query
+//run on all nodes, return max deleted count of nodes
+func (f *Client) DocRemoveByQuery(
+		indexTag string,
+		optJson *json.QueryOptJson,
+	) (int64, error) {
+	var (
+		deleted int64
+		lastErr error
+	)
+	//check
+	if indexTag == "" || optJson == nil {
+		return deleted, errors.New("invalid parameter")
+	}
+	if f.rpcClients == nil {
+		return deleted, errors.New("no any active rpc client")
+	}
+
+	optJsonByte, err := optJson.Encode()
+	if err != nil {
+		return deleted, err
+	}
+
+	//run on all rpc clients
+	f.RLock()
+	defer f.RUnlock()
+	for _, client := range f.rpcClients {
+		if !client.IsActive() {
+			continue
+		}
+		jsonByte, subErr := client.DocQuery(
+			QueryOptKindOfDelete,
+			indexTag,
+			optJsonByte,
+		)
+		if subErr != nil {
+			lastErr = subErr
+			continue
+		}
+		resultJson := json.NewSearchResultJson()
+		if subErr = resultJson.Decode(jsonByte); subErr != nil {
+			lastErr = subErr
+			continue
+		}
+		if int64(resultJson.Total) > deleted {
+			deleted = int64(resultJson.Total)
+		}
+	}
+	return deleted, lastErr
+}
+
 //add sync
 //used for add, sync doc, run on all nodes
+//boost used for rank doc higher in every query
 func (f *Client) DocSync(
 		indexTag, docId string,
 		docJson []byte,
+		boosts ...float64,
 	) error {
 	var (
 		m any = nil
@@ -301,6 +364,15 @@ func (f *Client) DocSync(
 		return errors.New("no any active rpc client")
 	}
 
+	//check and set doc boost
+	if boosts != nil && len(boosts) > 0 && boosts[0] > 0 {
+		boostJson, err := f.setDocBoost(docJson, boosts[0])
+		if err != nil {
+			return err
+		}
+		docJson = boostJson
+	}
+
 	//defer
 	defer func() {
 		if e := recover(); e != m {
@@ -337,6 +409,150 @@ func (f *Client) CreateIndex(indexTag string) error {
 	return err
 }
 
+//set synonym set of index, run on all nodes
+//query key expanded by it, take effect without restart or reindex
+func (f *Client) SetSynonyms(
+		indexTag string,
+		synonyms *json.SynonymSetJson,
+	) error {
+	var (
+		lastErr error
+	)
+	//check
+	if indexTag == "" || synonyms == nil {
+		return errors.New("invalid parameter")
+	}
+	if f.rpcClients == nil {
+		return errors.New("no any active rpc client")
+	}
+	jsonByte, err := synonyms.Encode()
+	if err != nil {
+		return err
+	}
+
+	//run on all rpc clients
+	f.RLock()
+	defer f.RUnlock()
+	for _, client := range f.rpcClients {
+		if !client.IsActive() {
+			continue
+		}
+		_, subErr := client.DocQuery(
+			QueryOptKindOfSetSynonym,
+			indexTag,
+			jsonByte,
+		)
+		if subErr != nil {
+			lastErr = subErr
+		}
+	}
+	return lastErr
+}
+
+//get synonym set of index
+func (f *Client) GetSynonyms(indexTag string) (*json.SynonymSetJson, error) {
+	//check
+	if indexTag == "" {
+		return nil, errors.New("invalid parameter")
+	}
+	//get rpc client
+	client := f.getClient()
+	if client == nil {
+		return nil, errors.New("can't get active rpc client")
+	}
+	//call rpc api
+	jsonByte, err := client.DocQuery(
+		QueryOptKindOfGetSynonym,
+		indexTag,
+		[]byte("{}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	synonyms := json.NewSynonymSetJson()
+	err = synonyms.Decode(jsonByte)
+	return synonyms, err
+}
+
+//add or replace saved query of index, run on all nodes
+//new doc synced into index will be checked by it
+func (f *Client) AddPercolateQuery(
+		indexTag, id string,
+		opt *json.QueryOptJson,
+	) error {
+	//check
+	if indexTag == "" || id == "" || opt == nil {
+		return errors.New("invalid parameter")
+	}
+	query := json.NewPercolateQueryJson()
+	query.Id = id
+	query.Query = opt
+	return f.percolateOnAllNodes(QueryOptKindOfAddPercolate, indexTag, query)
+}
+
+//remove saved query of index, run on all nodes
+func (f *Client) RemovePercolateQuery(indexTag, id string) error {
+	//check
+	if indexTag == "" || id == "" {
+		return errors.New("invalid parameter")
+	}
+	query := json.NewPercolateQueryJson()
+	query.Id = id
+	return f.percolateOnAllNodes(QueryOptKindOfRemovePercolate, indexTag, query)
+}
+
+//get saved queries of index
+func (f *Client) GetPercolateQueries(indexTag string) ([]*json.PercolateQueryJson, error) {
+	//check
+	if indexTag == "" {
+		return nil, errors.New("invalid parameter")
+	}
+	//get rpc client
+	client := f.getClient()
+	if client == nil {
+		return nil, errors.New("can't get active rpc client")
+	}
+	//call rpc api
+	jsonByte, err := client.DocQuery(
+		QueryOptKindOfGetPercolate,
+		indexTag,
+		[]byte("{}"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	percolates := json.NewPercolateSetJson()
+	err = percolates.Decode(jsonByte)
+	return percolates.Queries, err
+}
+
+//watch percolate matches of indexes
+//tag support pattern like 'posts-*', empty means all indexes
+//docs synced into all nodes, so only one node watched
+func (f *Client) WatchPercolate(
+		indexTag string,
+		cb func(match *json.PercolateMatchJson),
+	) error {
+	//check
+	if cb == nil {
+		return errors.New("invalid parameter")
+	}
+	//get rpc client
+	client := f.getClient()
+	if client == nil {
+		return errors.New("can't get active rpc client")
+	}
+	//call rpc api
+	return client.DocPercolate(indexTag, func(jsonByte []byte) {
+		match := json.NewPercolateMatchJson()
+		if err := match.Decode(jsonByte); err != nil {
+			log.Printf("client.WatchPercolate decode match failed, err:%v\n", err)
+			return
+		}
+		cb(match)
+	})
+}
+
 //add search service nodes
 func (f *Client) AddNodes(nodes ... string) error {
 	//check
@@ -363,6 +579,23 @@ func (f *Client) AddNodes(nodes ... string) error {
 //private func
 //////////////
 
+//set doc boost field into doc json
+//number kept as original text, avoid precision lost of big int
+func (f *Client) setDocBoost(
+		docJson []byte,
+		boost float64,
+	) ([]byte, error) {
+	kvMap := make(map[string]interface{})
+	decoder := genJson.NewDecoder(bytes.NewReader(docJson))
+	decoder.UseNumber()
+	err := decoder.Decode(&kvMap)
+	if err != nil {
+		return nil, err
+	}
+	kvMap[define.DocFieldOfBoost] = boost
+	return json.NewBaseJson().EncodeSimple(kvMap)
+}
+
 //inter init
 func (f *Client) interInit() {
 	//init workers
@@ -427,6 +660,42 @@ func (f *Client) cbForWorkerOpt(input interface{}) (interface{}, error) {
 	return nil, nil
 }
 
+//add or remove saved query on all nodes
+func (f *Client) percolateOnAllNodes(
+		queryKind int,
+		indexTag string,
+		query *json.PercolateQueryJson,
+	) error {
+	var (
+		lastErr error
+	)
+	if f.rpcClients == nil {
+		return errors.New("no any active rpc client")
+	}
+	jsonByte, err := query.Encode()
+	if err != nil {
+		return err
+	}
+
+	//run on all rpc clients
+	f.RLock()
+	defer f.RUnlock()
+	for _, client := range f.rpcClients {
+		if !client.IsActive() {
+			continue
+		}
+		_, subErr := client.DocQuery(
+			queryKind,
+			indexTag,
+			jsonByte,
+		)
+		if subErr != nil {
+			lastErr = subErr
+		}
+	}
+	return lastErr
+}
+
 //query batch doc
 func (f *Client) queryDoc(
 		req *queryDocReq,
@@ -448,6 +717,7 @@ func (f *Client) queryDoc(
 	}
 
 	//call api
+	beginAt := time.Now()
 	jsonByte, subErr := client.DocQuery(
 		QueryOptKindOfGen,
 		req.indexTag,
@@ -465,7 +735,16 @@ func (f *Client) queryDoc(
 	//format result
 	resultJson := json.NewSearchResultJson()
 	err = resultJson.Decode(jsonByte)
-	return resultJson, err
+	if err != nil {
+		return nil, err
+	}
+
+	//set rpc round trip time, in milliseconds
+	if resultJson.Timings == nil {
+		resultJson.Timings = map[string]float64{}
+	}
+	resultJson.Timings[define.QueryPhaseOfRpc] = float64(time.Since(beginAt).Microseconds()) / 1000
+	return resultJson, nil
 }
 
 //get one doc
@@ -0,0 +1,42 @@
+package tinysearch
+
+import (
+	"bytes"
+	genJson "encoding/json"
+	"github.com/andyzhou/tinysearch/define"
+	"testing"
+)
+
+//test doc boost set without number precision lost
+func TestClientSetDocBoost(t *testing.T) {
+	client := &Client{}
+	docJson := []byte(`{"id":9007199254740993,"price":10.25,"title":"apple"}`)
+	boostJson, err := client.setDocBoost(docJson, 2.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//check big int kept
+	kvMap := make(map[string]interface{})
+	decoder := genJson.NewDecoder(bytes.NewReader(boostJson))
+	decoder.UseNumber()
+	err = decoder.Decode(&kvMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := kvMap["id"].(genJson.Number).String(); id != "9007199254740993" {
+		t.Fatalf("id %v, expect 9007199254740993", id)
+	}
+	if price := kvMap["price"].(genJson.Number).String(); price != "10.25" {
+		t.Fatalf("price %v, expect 10.25", price)
+	}
+	if boost, _ := kvMap[define.DocFieldOfBoost].(genJson.Number).Float64(); boost != 2.5 {
+		t.Fatalf("boost %v, expect 2.5", boost)
+	}
+
+	//invalid doc json
+	_, err = client.setDocBoost([]byte(`[1,2]`), 2.5)
+	if err == nil {
+		t.Fatal("invalid doc json should be rejected")
+	}
+}
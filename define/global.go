@@ -13,6 +13,44 @@ const (
 
 	InterDefaultGroup     = "__group__"
 	InterSuggestIndexPara = "__suggester_%v"
+	InterCollapseFacet    = "__collapse__"
+
+	QueryRescoreWindow     = 500  //top hits for rescoring
+	DeleteByQueryBatchSize = 1000 //docs removed per batch
+	FuzzinessDefault       = 1    //edit distance, max 2
+	SynonymExpandMax       = 16   //max expanded keys of one query key
+	SpellSuggestSize       = 3    //max spell suggestions
+	SpellCandidateMax      = 10   //max corrected candidates of one term
+	MoreLikeThisMaxTerms   = 25   //max selected terms of source doc
+	QueryCacheTTLDefault   = 60   //seconds
+	PercolateQueriesMax    = 1000 //max saved queries of one index
+	PercolateChanSize      = 1024 //buffered matches of one stream
+	CollapseWindow         = 1000 //top hits grouped by collapse field
+	QueryTimeoutCheckEvery = 256  //docs matched between deadline checks
+
+	IndexCheckTicker          = 10 //seconds
+	IndexEvictGraceSeconds    = 2  //active index in it can't be evicted
+	RolloverDateFormatDefault = "2006.01.02"
+)
+
+//inter doc field and index internal key
+const (
+	DocFieldOfBoost     = "_boost"
+	DocFieldOfSource    = "_source" //original doc json, store only
+	InterKeyOfBoosted   = "_boosted"
+	InterKeyOfSchema    = "_schema"
+	InterKeyOfSynonym   = "_synonym"
+	InterKeyOfPercolate = "_percolate"
+)
+
+//field type
+const (
+	FieldTypeOfText     = "text"
+	FieldTypeOfInt      = "int"
+	FieldTypeOfFloat    = "float"
+	FieldTypeOfBool     = "boolean"
+	FieldTypeOfDateTime = "datetime"
+	FieldTypeOfGeoPoint = "geopoint"
 )
 
 //default value
@@ -26,6 +64,12 @@ const (
 	QueryOptKindOfGen = iota
 	QueryOptKindOfAgg
 	QueryOptKindOfSuggest
+	QueryOptKindOfDelete
+	QueryOptKindOfGetSynonym
+	QueryOptKindOfSetSynonym
+	QueryOptKindOfAddPercolate
+	QueryOptKindOfRemovePercolate
+	QueryOptKindOfGetPercolate
 )
 
 //query kind
@@ -38,6 +82,76 @@ const (
 	QueryKindOfPrefix
 	QueryKindOfGeoDistance
 	QueryKindOfConjunctionQuery
+	QueryKindOfQueryString  //like 'title:phone +cat:job-1 price:>10'
+	QueryKindOfFuzzy        //typo tolerant match, with fuzziness and prefix length
+	QueryKindOfWildcard     //like 'iph*ne'
+	QueryKindOfRegexp       //like 'iph[a-z]+'
+	QueryKindOfMoreLikeThis //similar docs of doc id or text
+)
+
+//sort field para
+const (
+	SortFieldOfScore   = "_score"
+	SortFieldOfId      = "_id"
+	SortTypeOfString   = "string"
+	SortTypeOfNumber   = "number"
+	SortTypeOfDate     = "date"
+	SortModeOfMin      = "min"
+	SortModeOfMax      = "max"
+	SortMissingOfFirst = "first"
+	SortMissingOfLast  = "last"
+)
+
+//score function para
+const (
+	ScoreFuncOfGauss      = "gauss"
+	ScoreFuncOfExp        = "exp"
+	ScoreFuncOfLinear     = "linear"
+	ScoreFuncOfFieldValue = "fieldValue"
+	ScoreFuncOfWeight     = "weight"
+
+	ScoreModeOfMultiply = "multiply"
+	ScoreModeOfSum      = "sum"
+	ScoreModeOfAvg      = "avg"
+	ScoreModeOfMax      = "max"
+	ScoreModeOfMin      = "min"
+	ScoreModeOfFirst    = "first"
+	ScoreModeOfReplace  = "replace" //only for boost mode
+
+	ScoreDecayDefault = 0.5
+)
+
+//query phase for timings
+const (
+	QueryPhaseOfBuild   = "build"
+	QueryPhaseOfSearch  = "search"
+	QueryPhaseOfRescore = "rescore"
+	QueryPhaseOfFormat  = "format"
+	QueryPhaseOfSpell   = "spell"
+	QueryPhaseOfCache   = "cache"
+	QueryPhaseOfRpc     = "rpc"
+)
+
+//range filter value type
+const (
+	RangeTypeOfNumber = "number"
+	RangeTypeOfDate   = "date"
+	RangeTypeOfTerm   = "term"
+)
+
+//high light para
+const (
+	HighLightStyleOfHtml = "html"
+	HighLightStyleOfAnsi = "ansi"
+
+	HighLightFragmentSize = 200
+	HighLightFragmentNum  = 1
+)
+
+//multi fields match kind
+const (
+	MultiFieldKindOfBest = iota //score by best matched field
+	MultiFieldKindOfMost        //score by sum of matched fields
 )
 
 //filter kind
@@ -52,4 +166,13 @@ const (
 	FilterKindPrefix
 	FilterKindBoolean
 	FilterKindTermsQuery
+	FilterKindFuzzy
+	FilterKindWildcard
+	FilterKindRegexp
+	FilterKindGeoDistance
+	FilterKindGeoBoundingBox
+	FilterKindGeoPolygon
+	FilterKindRange
+	FilterKindExists  //field has any value, all terms of field read, costly for many distinct values
+	FilterKindMissing //field has no value, same cost as exists
 )
\ No newline at end of file
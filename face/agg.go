@@ -1,6 +1,7 @@
 package face
 
 import (
+	"context"
 	genJson "encoding/json"
 	"errors"
 	"fmt"
@@ -19,6 +20,7 @@ import (
 //face info
 type Agg struct {
 	query iface.IQuery //reference
+	Base
 }
 
 //construct
@@ -35,8 +37,18 @@ func (f *Agg) GetAggList(
 		index iface.IIndex,
 		opt *json.QueryOptJson,
 	) (*json.AggregatesJson, error) {
+	return f.GetAggListWithContext(context.Background(), index, opt)
+}
+
+//get agg list with context
+//stopped if context done, partial result returned if timeout of opt reached
+func (f *Agg) GetAggListWithContext(
+		ctx context.Context,
+		index iface.IIndex,
+		opt *json.QueryOptJson,
+	) (*json.AggregatesJson, error) {
 	//basic check
-	if index == nil || opt == nil {
+	if ctx == nil || index == nil || opt == nil {
 		return nil, errors.New("invalid parameter")
 	}
 
@@ -45,7 +57,8 @@ func (f *Agg) GetAggList(
 	}
 
 	//get index
-	indexer := index.GetIndex()
+	indexer := index.AcquireIndex()
+	defer index.ReleaseIndex()
 	if indexer == nil {
 		return nil, errors.New("can't get indexer")
 	}
@@ -56,7 +69,10 @@ func (f *Agg) GetAggList(
 	}
 
 	//build search request
-	searchRequest := f.query.BuildSearchReq(opt)
+	searchRequest, err := f.query.BuildSearchReq(opt)
+	if err != nil {
+		return nil, err
+	}
 
 	//add batch aggregating facet
 	tempAggFieldMap := map[string]*json.AggField{}
@@ -75,17 +91,24 @@ func (f *Agg) GetAggList(
 		tempAggFieldMap[facetName] = aggField
 	}
 
-	//begin search
-	searchResult, err := indexer.Search(searchRequest)
+	//begin search, stopped if context done or timeout
+	timeLimit := f.setTimeLimit(searchRequest, opt.Timeout)
+	searchResult, err := indexer.SearchInContext(ctx, searchRequest)
 	if err != nil {
 		return nil, err
 	}
-	if searchResult.Facets == nil || len(searchResult.Facets) <= 0 {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	timedOut, searchErrors := f.getSearchErrors(index.GetTag(), searchResult.Status, timeLimit)
+	if len(searchResult.Facets) <= 0 && len(searchErrors) <= 0 {
 		return nil, nil
 	}
 
 	//init final result
 	result := json.NewAggregatesJson()
+	result.TimedOut = timedOut
+	result.Partial = len(searchErrors) > 0
 
 	//format facet result
 	for facetName, facetResult := range searchResult.Facets {
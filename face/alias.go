@@ -0,0 +1,229 @@
+package face
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andyzhou/tinysearch/iface"
+	"github.com/andyzhou/tinysearch/json"
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/mapping"
+	index "github.com/blevesearch/bleve_index_api"
+	"hash/fnv"
+	"time"
+)
+
+/*
+ * face for alias index
+ * @author <AndyZhou>
+ * @mail <diudiu8848@163.com>
+ * - search batch indexes by pattern tag, like 'logs-*'
+ * - read only, can't opt doc write
+ * - sub indexes acquired together, alias indexer built on each acquire
+ */
+
+//inter alias indexer
+//used for get doc from sub indexes
+type aliasIndexer struct {
+	bleve.IndexAlias
+	indexes []bleve.Index
+}
+
+//face info
+type AliasIndex struct {
+	tag     string //pattern tag
+	indexes []iface.IIndex
+}
+
+//construct
+func NewAliasIndex(tag string, indexes ...iface.IIndex) *AliasIndex {
+	//self init
+	this := &AliasIndex{
+		tag: tag,
+		indexes: indexes,
+	}
+	return this
+}
+
+//get index tag
+func (f *AliasIndex) GetTag() string {
+	return f.tag
+}
+
+//close alias, sub indexes still opening
+func (f *AliasIndex) Close() error {
+	return nil
+}
+
+//unload alias, same as close
+func (f *AliasIndex) Unload() error {
+	return nil
+}
+
+//alias always opened
+func (f *AliasIndex) IsOpened() bool {
+	return true
+}
+
+//get active time
+func (f *AliasIndex) GetActiveTime() time.Time {
+	return time.Now()
+}
+
+//get merged schema of sub indexes
+func (f *AliasIndex) GetSchema() map[string]string {
+	result := make(map[string]string)
+	for _, v := range f.indexes {
+		for field, kind := range v.GetSchema() {
+			result[field] = kind
+		}
+	}
+	return result
+}
+
+//merge schema, not support
+func (f *AliasIndex) MergeSchema(fields map[string]string) error {
+	return errors.New("alias index can't merge schema")
+}
+
+//get write version, hash of sub indexes tag and version
+//changed if any sub index changed, added or removed
+func (f *AliasIndex) GetVersion() int64 {
+	h := fnv.New64a()
+	for _, v := range f.indexes {
+		fmt.Fprintf(h, "%s:%d;", v.GetTag(), v.GetVersion())
+	}
+	return int64(h.Sum64())
+}
+
+//increase write version of all sub indexes
+//used after docs changed through alias
+func (f *AliasIndex) IncVersion() {
+	for _, v := range f.indexes {
+		v.IncVersion()
+	}
+}
+
+//get merged synonym set of sub indexes
+func (f *AliasIndex) GetSynonyms() *json.SynonymSetJson {
+	result := json.NewSynonymSetJson()
+	for _, v := range f.indexes {
+		result.Rules = append(result.Rules, v.GetSynonyms().Rules...)
+	}
+	return result
+}
+
+//set synonym set, not support
+func (f *AliasIndex) SetSynonyms(synonyms *json.SynonymSetJson) error {
+	return errors.New("alias index can't set synonyms")
+}
+
+//get merged saved query set of sub indexes
+func (f *AliasIndex) GetPercolates() *json.PercolateSetJson {
+	result := json.NewPercolateSetJson()
+	for _, v := range f.indexes {
+		result.Queries = append(result.Queries, v.GetPercolates().Queries...)
+	}
+	return result
+}
+
+//set saved query set, not support
+func (f *AliasIndex) SetPercolates(percolates *json.PercolateSetJson) error {
+	return errors.New("alias index can't set percolate queries")
+}
+
+//remove index, not support
+func (f *AliasIndex) RemoveIndex() error {
+	return errors.New("alias index can't be removed")
+}
+
+//get index
+func (f *AliasIndex) GetIndex() bleve.Index {
+	indexers := make([]bleve.Index, 0)
+	for _, v := range f.indexes {
+		indexer := v.GetIndex()
+		if indexer != nil {
+			indexers = append(indexers, indexer)
+		}
+	}
+	return f.newIndexer(indexers)
+}
+
+//acquire all sub indexes
+//should call `ReleaseIndex` after used
+func (f *AliasIndex) AcquireIndex() bleve.Index {
+	indexers := make([]bleve.Index, 0)
+	for _, v := range f.indexes {
+		indexer := v.AcquireIndex()
+		if indexer != nil {
+			indexers = append(indexers, indexer)
+		}
+	}
+	return f.newIndexer(indexers)
+}
+
+//release all sub indexes
+func (f *AliasIndex) ReleaseIndex() {
+	for _, v := range f.indexes {
+		v.ReleaseIndex()
+	}
+}
+
+//create index, not support
+func (f *AliasIndex) CreateIndex() error {
+	return errors.New("alias index can't be created")
+}
+
+//create chinese index mapping, not support
+func (f *AliasIndex) CreateChineseMap(dictPath string) (*mapping.IndexMappingImpl, error) {
+	return nil, errors.New("alias index has no mapping")
+}
+
+//set tokenizer file, not support
+func (f *AliasIndex) SetDictPath(dict string) bool {
+	return false
+}
+
+//////////////
+//private func
+//////////////
+
+//build alias indexer of sub indexers
+func (f *AliasIndex) newIndexer(indexers []bleve.Index) *aliasIndexer {
+	return &aliasIndexer{
+		IndexAlias: bleve.NewIndexAlias(indexers...),
+		indexes: indexers,
+	}
+}
+
+/////////////////////////
+//api for alias indexer
+/////////////////////////
+
+//get doc from sub indexes
+func (f *aliasIndexer) Document(id string) (index.Document, error) {
+	for _, v := range f.indexes {
+		doc, err := v.Document(id)
+		if err != nil {
+			return nil, err
+		}
+		if doc != nil {
+			return doc, nil
+		}
+	}
+	return nil, nil
+}
+
+//get internal value from sub indexes
+//return first not empty value
+func (f *aliasIndexer) GetInternal(key []byte) ([]byte, error) {
+	for _, v := range f.indexes {
+		val, err := v.GetInternal(key)
+		if err != nil {
+			return nil, err
+		}
+		if val != nil {
+			return val, nil
+		}
+	}
+	return nil, nil
+}
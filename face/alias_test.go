@@ -0,0 +1,80 @@
+package face
+
+import (
+	"github.com/andyzhou/tinysearch/define"
+	"github.com/andyzhou/tinysearch/json"
+	"testing"
+	"time"
+)
+
+//test version of alias changed with sub indexes
+func TestAliasIncVersion(t *testing.T) {
+	m := newTestManager(t)
+	sub1 := newTestIndex(t, m, "logs-1")
+	sub2 := newTestIndex(t, m, "logs-2")
+	alias := m.GetQueryIndex("logs-*")
+	if alias == nil {
+		t.Fatal("can't get alias index")
+	}
+	v1, v2, version := sub1.GetVersion(), sub2.GetVersion(), alias.GetVersion()
+
+	//changed through alias, forwarded to sub indexes
+	alias.IncVersion()
+	if sub1.GetVersion() == v1 || sub2.GetVersion() == v2 {
+		t.Fatal("version of sub indexes not changed")
+	}
+	if alias.GetVersion() == version {
+		t.Fatal("version of alias not changed")
+	}
+
+	//changed by sub index
+	version = alias.GetVersion()
+	sub1.IncVersion()
+	if alias.GetVersion() == version {
+		t.Fatal("version of alias not changed by sub index")
+	}
+}
+
+//test cached result of alias invalidated by doc changes
+func TestAliasQueryCache(t *testing.T) {
+	m := newTestManager(t)
+	m.GetQuery().SetCache(100, time.Minute)
+	sub := newTestIndex(t, m, "logs-1")
+	addTestDocs(t, m, sub, map[string]map[string]interface{}{
+		"1": {"cat": "red"},
+	})
+	alias := m.GetQueryIndex("logs-*")
+	getTotal := func() uint64 {
+		opt := json.NewQueryOptJson()
+		opt.QueryKind = define.QueryKindOfMatchAll
+		opt.Size = 10
+		result, err := m.GetQuery().Query(alias, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result.Total
+	}
+	if total := getTotal(); total != 1 {
+		t.Fatalf("total %v, expect 1", total)
+	}
+
+	//doc added by sub index
+	addTestDocs(t, m, sub, map[string]map[string]interface{}{
+		"2": {"cat": "green"},
+	})
+	if total := getTotal(); total != 2 {
+		t.Fatalf("total %v after add, expect 2", total)
+	}
+
+	//doc removed by indexer of alias
+	indexer := alias.AcquireIndex()
+	err := indexer.(*aliasIndexer).indexes[0].Delete("1")
+	alias.ReleaseIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	alias.IncVersion()
+	if total := getTotal(); total != 1 {
+		t.Fatalf("total %v after remove, expect 1", total)
+	}
+}
@@ -2,11 +2,23 @@ package face
 
 import (
 	"bytes"
+	genJson "encoding/json"
+	"errors"
+	"github.com/andyzhou/tinysearch/define"
 	"github.com/andyzhou/tinysearch/json"
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis"
 	"github.com/blevesearch/bleve/v2/document"
+	"github.com/blevesearch/bleve/v2/geo"
+	"github.com/blevesearch/bleve/v2/mapping"
 	"github.com/blevesearch/bleve/v2/search"
 	index "github.com/blevesearch/bleve_index_api"
 	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
 )
 
 /*
@@ -42,6 +54,7 @@ func (f *Base) GetSubDirs(dataPath string) ([]string, error) {
 func (f *Base) AnalyzeDoc(
 		doc index.Document,
 		hit *search.DocumentMatch,
+		schemas ...map[string]string,
 	) (*json.HitDocJson, error) {
 	var (
 		jsonByte []byte
@@ -54,11 +67,17 @@ func (f *Base) AnalyzeDoc(
 	if doc != nil {
 		//init one doc object
 		jsonObj := json.NewBaseJson()
-		genMap := f.FormatDoc(doc)
+		genMap := f.FormatDoc(doc, schemas...)
 		if genMap == nil {
 			return nil, nil
 		}
 
+		//pick up inter boost field
+		if v, ok := genMap[define.DocFieldOfBoost]; ok {
+			hitDocJson.Boost, _ = v.(float64)
+			delete(genMap, define.DocFieldOfBoost)
+		}
+
 		//get json byte
 		jsonByte, err = jsonObj.EncodeSimple(genMap)
 		if err != nil {
@@ -70,6 +89,7 @@ func (f *Base) AnalyzeDoc(
 	if hit != nil {
 		hitDocJson.Id = hit.ID
 		hitDocJson.Score = hit.Score
+		hitDocJson.Explanation = f.FormatExplain(hit.Expl)
 	}else{
 		hitDocJson.Id = doc.ID()
 	}
@@ -91,18 +111,113 @@ func (f *Base) AnalyzeDoc(
 	return hitDocJson, nil
 }
 
+//analyze hit with stored fields, doc not need loaded
+//includes and excludes are path patterns, like 'user.*'
+func (f *Base) AnalyzeHit(
+		hit *search.DocumentMatch,
+		schema map[string]string,
+		includes, excludes []string,
+	) (*json.HitDocJson, error) {
+	//basic check
+	if hit == nil {
+		return nil, nil
+	}
+
+	//init hit doc json with score and high light
+	hitDocJson, err := f.AnalyzeDoc(nil, hit)
+	if err != nil || hitDocJson == nil {
+		return hitDocJson, err
+	}
+
+	//format stored fields
+	genMap := f.FormatHitFields(hit.Fields, schema)
+
+	//pick up inter boost field
+	if v, ok := genMap[define.DocFieldOfBoost]; ok {
+		hitDocJson.Boost, _ = v.(float64)
+		delete(genMap, define.DocFieldOfBoost)
+	}
+
+	//filter fields and get json byte
+	genMap = f.FilterSource(genMap, includes, excludes)
+	jsonObj := json.NewBaseJson()
+	hitDocJson.OrgJson, err = jsonObj.EncodeSimple(genMap)
+	if err != nil {
+		return nil, err
+	}
+	return hitDocJson, nil
+}
+
+//format stored fields of hit
+//original doc json will be used first if stored
+func (f *Base) FormatHitFields(
+		fields map[string]interface{},
+		schema map[string]string,
+	) map[string]interface{} {
+	//use original doc json if stored
+	if source, ok := fields[define.DocFieldOfSource].(string); ok {
+		sourceMap, err := f.DecodeSource([]byte(source))
+		if err == nil {
+			if v, ok := fields[define.DocFieldOfBoost]; ok {
+				sourceMap[define.DocFieldOfBoost] = v
+			}
+			f.formatSourceGeo(sourceMap, schema)
+			return sourceMap
+		}
+	}
+
+	//format typed values by schema
+	genMap := make(map[string]interface{})
+	for k, v := range fields {
+		if k == define.DocFieldOfSource {
+			continue
+		}
+		if val, ok := v.(float64); ok && schema[k] == define.FieldTypeOfInt {
+			genMap[k] = int64(val)
+			continue
+		}
+		//stored geo point is [lon, lat]
+		if val, ok := v.([]float64); ok && len(val) == 2 {
+			genMap[k] = f.FormatGeoPoint(val[0], val[1])
+			continue
+		}
+		genMap[k] = v
+	}
+	return genMap
+}
+
+//filter doc fields by includes and excludes path patterns
+//path like 'user.name', pattern like 'user.*'
+func (f *Base) FilterSource(
+		source map[string]interface{},
+		includes, excludes []string,
+	) map[string]interface{} {
+	if len(includes) <= 0 && len(excludes) <= 0 {
+		return source
+	}
+	return f.filterSourceMap("", source, f.splitPaths(includes), f.splitPaths(excludes))
+}
+
 //format one doc
+//schema used for format typed values, like int64, datetime, geo point
+//original doc json will be used first if stored
 func (f *Base) FormatDoc(
 		doc index.Document,
+		schemas ...map[string]string,
 	) map[string]interface{} {
 	var (
 		fieldName string
+		schema map[string]string
+		source []byte
 	)
 
 	//basic check
 	if doc == nil {
 		return nil
 	}
+	if schemas != nil && len(schemas) > 0 {
+		schema = schemas[0]
+	}
 
 	//format result
 	genMap := make(map[string]interface{})
@@ -115,7 +230,11 @@ func (f *Base) FormatDoc(
 			{
 				v, ok := field.(*document.TextField)
 				if ok {
-					genMap[fieldName] = string(v.Value())
+					if fieldName == define.DocFieldOfSource {
+						source = v.Value()
+					}else{
+						genMap[fieldName] = string(v.Value())
+					}
 				}
 			}
 			break
@@ -125,7 +244,11 @@ func (f *Base) FormatDoc(
 				if ok {
 					numericValue, err := v.Number()
 					if err == nil {
-						genMap[fieldName] = numericValue
+						if schema[fieldName] == define.FieldTypeOfInt {
+							genMap[fieldName] = int64(numericValue)
+						}else{
+							genMap[fieldName] = numericValue
+						}
 					}
 				}
 			}
@@ -147,7 +270,11 @@ func (f *Base) FormatDoc(
 				if ok {
 					dateValue, _, err := v.DateTime()
 					if err == nil {
-						genMap[fieldName] = dateValue.Unix()
+						if schema[fieldName] == define.FieldTypeOfDateTime {
+							genMap[fieldName] = dateValue.Format(time.RFC3339Nano)
+						}else{
+							genMap[fieldName] = dateValue.Unix()
+						}
 					}
 				}
 			}
@@ -158,14 +285,533 @@ func (f *Base) FormatDoc(
 				if ok {
 					latVal, _ := v.Lat()
 					lonVal, _ := v.Lon()
-					genMap[fieldName] = []interface{}{
-						latVal,
-						lonVal,
-					}
+					genMap[fieldName] = f.FormatGeoPoint(lonVal, latVal)
 				}
 			}
 			break
 		}
 	})
+
+	//use original doc json if stored
+	if source != nil {
+		sourceMap, err := f.DecodeSource(source)
+		if err == nil {
+			if v, ok := genMap[define.DocFieldOfBoost]; ok {
+				sourceMap[define.DocFieldOfBoost] = v
+			}
+			f.formatSourceGeo(sourceMap, schema)
+			return sourceMap
+		}
+	}
 	return genMap
-}
\ No newline at end of file
+}
+
+//format score explanation
+func (f *Base) FormatExplain(expl *search.Explanation) *json.ExplainJson {
+	if expl == nil {
+		return nil
+	}
+	result := &json.ExplainJson{
+		Value: expl.Value,
+		Message: expl.Message,
+	}
+	for _, v := range expl.Children {
+		if child := f.FormatExplain(v); child != nil {
+			result.Children = append(result.Children, child)
+		}
+	}
+	return result
+}
+
+//format geo point as geo json
+func (f *Base) FormatGeoPoint(lon, lat float64) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "Point",
+		"coordinates": []float64{lon, lat},
+	}
+}
+
+//parse geo point
+//support geo json, 'lat,lon', geo hash, {lat, lon} and [lon, lat]
+func (f *Base) ParseGeoPoint(val interface{}) (float64, float64, bool) {
+	val = f.convertNumber(val)
+	if v, ok := val.(map[string]interface{}); ok && f.isGeoJsonPoint(v) {
+		return geo.ExtractGeoPoint(v["coordinates"])
+	}
+	return geo.ExtractGeoPoint(val)
+}
+
+//convert geo json points of doc value into {lat, lon}, which can be indexed
+func (f *Base) FormatGeoJson(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		{
+			if f.isGeoJsonPoint(v) {
+				if lon, lat, ok := f.ParseGeoPoint(v); ok {
+					return map[string]interface{}{"lon": lon, "lat": lat}
+				}
+				return v
+			}
+			for k, subVal := range v {
+				v[k] = f.FormatGeoJson(subVal)
+			}
+		}
+	case []interface{}:
+		for i, subVal := range v {
+			v[i] = f.FormatGeoJson(subVal)
+		}
+	}
+	return value
+}
+
+//parse time string
+//support 'now', custom layouts, RFC3339 and common layouts, unix seconds
+func (f *Base) ParseTime(val string, layouts ...string) (time.Time, bool) {
+	if val == "now" {
+		return time.Now(), true
+	}
+	allLayouts := append([]string{}, layouts...)
+	allLayouts = append(allLayouts,
+		time.RFC3339Nano,
+		"2006-01-02T15:04:05",
+		"2006-01-02 15:04:05",
+		"2006-01-02",
+	)
+	for _, layout := range allLayouts {
+		if t, err := time.Parse(layout, val); err == nil {
+			return t, true
+		}
+	}
+	if sec, err := strconv.ParseInt(val, 10, 64); err == nil {
+		return time.Unix(sec, 0), true
+	}
+	return time.Time{}, false
+}
+
+//decode original doc json, keep number precision
+func (f *Base) DecodeSource(source []byte) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	decoder := genJson.NewDecoder(bytes.NewReader(source))
+	decoder.UseNumber()
+	err := decoder.Decode(&result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+//get field type schema of doc
+func (f *Base) GetDocSchema(source []byte) map[string]string {
+	result := make(map[string]string)
+	sourceMap, err := f.DecodeSource(source)
+	if err != nil {
+		return result
+	}
+	f.getValueSchema("", sourceMap, result)
+	return result
+}
+
+//check index has original doc field or not
+func (f *Base) HasSourceField(indexer bleve.Index) bool {
+	if indexer == nil {
+		return false
+	}
+	indexMapping, err := f.getMapping(indexer)
+	if err != nil {
+		return false
+	}
+	impl, ok := indexMapping.(*mapping.IndexMappingImpl)
+	if !ok || impl == nil || impl.DefaultMapping == nil {
+		return false
+	}
+	_, ok = impl.DefaultMapping.Properties[define.DocFieldOfSource]
+	return ok
+}
+
+//////////////
+//private func
+//////////////
+
+//filter sub map of path
+//nil includes means all included
+func (f *Base) filterSourceMap(
+		path string,
+		source map[string]interface{},
+		includes, excludes [][]string,
+	) map[string]interface{} {
+	result := make(map[string]interface{})
+	for k, v := range source {
+		subPath := k
+		if path != "" {
+			subPath = path + "/" + k
+		}
+		//check excludes
+		if f.matchPaths(subPath, excludes, false) {
+			continue
+		}
+		subMap, isMap := v.(map[string]interface{})
+		if includes == nil || f.matchPaths(subPath, includes, false) {
+			//included, check excludes of children
+			if isMap && len(excludes) > 0 {
+				v = f.filterSourceMap(subPath, subMap, nil, excludes)
+			}
+			result[k] = v
+			continue
+		}
+		//check children included or not
+		if isMap && f.matchPaths(subPath, includes, true) {
+			subResult := f.filterSourceMap(subPath, subMap, includes, excludes)
+			if len(subResult) > 0 {
+				result[k] = subResult
+			}
+		}
+	}
+	return result
+}
+
+//check path matched patterns
+//if is prefix, check path is parent of pattern
+func (f *Base) matchPaths(
+		path string,
+		patterns [][]string,
+		isPrefix bool,
+	) bool {
+	depth := strings.Count(path, "/") + 1
+	for _, segments := range patterns {
+		if isPrefix {
+			if len(segments) <= depth {
+				continue
+			}
+			segments = segments[:depth]
+		}else if len(segments) != depth {
+			continue
+		}
+		if ok, _ := filepath.Match(strings.Join(segments, "/"), path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+//split path patterns as segments
+func (f *Base) splitPaths(patterns []string) [][]string {
+	if len(patterns) <= 0 {
+		return nil
+	}
+	result := make([][]string, 0)
+	for _, v := range patterns {
+		if v != "" {
+			result = append(result, strings.Split(v, "."))
+		}
+	}
+	return result
+}
+
+//get field type of value
+func (f *Base) getValueSchema(
+		path string,
+		value interface{},
+		result map[string]string,
+	) {
+	switch v := value.(type) {
+	case genJson.Number:
+		{
+			if _, err := v.Int64(); err == nil {
+				result[path] = define.FieldTypeOfInt
+			}else{
+				result[path] = define.FieldTypeOfFloat
+			}
+		}
+	case bool:
+		result[path] = define.FieldTypeOfBool
+	case string:
+		{
+			if _, err := time.Parse(time.RFC3339, v); err == nil {
+				result[path] = define.FieldTypeOfDateTime
+			}else{
+				result[path] = define.FieldTypeOfText
+			}
+		}
+	case []interface{}:
+		{
+			//use type of first element
+			if len(v) > 0 {
+				f.getValueSchema(path, v[0], result)
+			}
+		}
+	case map[string]interface{}:
+		{
+			//check geo point
+			if f.isGeoPoint(v) {
+				result[path] = define.FieldTypeOfGeoPoint
+				return
+			}
+			for k, subVal := range v {
+				if path == "" && (k == define.DocFieldOfSource || k == define.DocFieldOfBoost) {
+					continue
+				}
+				subPath := k
+				if path != "" {
+					subPath = path + "." + k
+				}
+				f.getValueSchema(subPath, subVal, result)
+			}
+		}
+	}
+}
+
+//format geo point fields of original doc as geo json
+func (f *Base) formatSourceGeo(
+		source map[string]interface{},
+		schema map[string]string,
+	) {
+	for path, kind := range schema {
+		if kind != define.FieldTypeOfGeoPoint {
+			continue
+		}
+		//find parent of field by path
+		parent := source
+		keys := strings.Split(path, ".")
+		for _, k := range keys[:len(keys)-1] {
+			sub, ok := parent[k].(map[string]interface{})
+			if !ok {
+				parent = nil
+				break
+			}
+			parent = sub
+		}
+		if parent == nil {
+			continue
+		}
+		key := keys[len(keys)-1]
+		if v, ok := parent[key]; ok {
+			parent[key] = f.formatGeoValue(v)
+		}
+	}
+}
+
+//format geo point value as geo json, support multi points
+func (f *Base) formatGeoValue(value interface{}) interface{} {
+	if lon, lat, ok := f.ParseGeoPoint(value); ok {
+		return f.FormatGeoPoint(lon, lat)
+	}
+	if values, ok := value.([]interface{}); ok {
+		result := make([]interface{}, 0, len(values))
+		for _, v := range values {
+			result = append(result, f.formatGeoValue(v))
+		}
+		return result
+	}
+	return value
+}
+
+//check value is geo point or not
+//support {"lat":x, "lon":y} and geo json point
+func (f *Base) isGeoPoint(v map[string]interface{}) bool {
+	if len(v) != 2 {
+		return false
+	}
+	if f.isGeoJsonPoint(v) {
+		return true
+	}
+	_, hasLat := v["lat"]
+	_, hasLon := v["lon"]
+	if !hasLon {
+		_, hasLon = v["lng"]
+	}
+	return hasLat && hasLon
+}
+
+//convert json number of value into float64, used for decoded original doc
+func (f *Base) convertNumber(value interface{}) interface{} {
+	switch v := value.(type) {
+	case genJson.Number:
+		{
+			num, err := v.Float64()
+			if err != nil {
+				return v
+			}
+			return num
+		}
+	case map[string]interface{}:
+		{
+			result := make(map[string]interface{}, len(v))
+			for k, subVal := range v {
+				result[k] = f.convertNumber(subVal)
+			}
+			return result
+		}
+	case []interface{}:
+		{
+			result := make([]interface{}, 0, len(v))
+			for _, subVal := range v {
+				result = append(result, f.convertNumber(subVal))
+			}
+			return result
+		}
+	}
+	return value
+}
+
+//check value is geo json point or not
+func (f *Base) isGeoJsonPoint(v map[string]interface{}) bool {
+	kind, ok := v["type"].(string)
+	if !ok || !strings.EqualFold(kind, "point") {
+		return false
+	}
+	_, ok = v["coordinates"]
+	return ok
+}
+
+//analyze text by analyzer of field
+func (f *Base) analyzeText(
+		indexer bleve.Index,
+		text string,
+		field string,
+	) (analysis.TokenStream, error) {
+	indexMapping, err := f.getMapping(indexer)
+	if err != nil {
+		return nil, err
+	}
+	analyzer := indexMapping.AnalyzerNamed(indexMapping.AnalyzerNameForPath(field))
+	if analyzer == nil {
+		return nil, errors.New("can't get analyzer of field " + field)
+	}
+	return analyzer.Analyze([]byte(text)), nil
+}
+
+//get index mapping, first sub index used for alias
+//alias of multi indexes has no mapping
+func (f *Base) getMapping(indexer bleve.Index) (mapping.IndexMapping, error) {
+	if indexer == nil {
+		return nil, errors.New("invalid parameter")
+	}
+	if alias, ok := indexer.(*aliasIndexer); ok {
+		if len(alias.indexes) <= 0 {
+			return nil, errors.New("alias has no any sub index")
+		}
+		indexer = alias.indexes[0]
+	}
+	indexMapping := indexer.Mapping()
+	if indexMapping == nil {
+		return nil, errors.New("can't get mapping of index")
+	}
+	return indexMapping, nil
+}
+
+//get default search field of index mapping
+func (f *Base) getDefaultField(indexer bleve.Index) (string, error) {
+	indexMapping, err := f.getMapping(indexer)
+	if err != nil {
+		return "", err
+	}
+	return indexMapping.DefaultSearchField(), nil
+}
+
+//get index readers, sub indexes used for alias
+func (f *Base) getReaders(indexer bleve.Index) ([]index.IndexReader, error) {
+	indexers := []bleve.Index{indexer}
+	if alias, ok := indexer.(*aliasIndexer); ok {
+		indexers = alias.indexes
+	}
+	readers := make([]index.IndexReader, 0, len(indexers))
+	for _, v := range indexers {
+		advanced, err := v.Advanced()
+		if err != nil {
+			return readers, err
+		}
+		reader, err := advanced.Reader()
+		if err != nil {
+			return readers, err
+		}
+		readers = append(readers, reader)
+	}
+	return readers, nil
+}
+
+//get doc frequency of term in fields
+func (f *Base) getTermFreq(
+		readers []index.IndexReader,
+		fields []string,
+		term string,
+	) uint64 {
+	freqs := make(map[string]uint64)
+	for _, reader := range readers {
+		for _, field := range fields {
+			dict, err := reader.FieldDictRange(field, []byte(term), []byte(term))
+			if err != nil {
+				continue
+			}
+			f.readDict(dict, freqs)
+		}
+	}
+	return freqs[term]
+}
+
+//read dict entries into freqs
+func (f *Base) readDict(
+		dict index.FieldDict,
+		freqs map[string]uint64,
+	) {
+	defer dict.Close()
+	for {
+		entry, err := dict.Next()
+		if err != nil || entry == nil {
+			return
+		}
+		freqs[entry.Term] += entry.Count
+	}
+}
+
+//check term has letter or digit
+func (f *Base) isWord(term string) bool {
+	for _, r := range term {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return true
+		}
+	}
+	return false
+}
+
+//wrap query of search request with timeout of query opt
+//timeout in milliseconds, <= 0 means no limit and return nil
+func (f *Base) setTimeLimit(
+		searchRequest *bleve.SearchRequest,
+		timeout int,
+	) *timeLimitQuery {
+	if timeout <= 0 {
+		return nil
+	}
+	deadline := time.Now().Add(time.Duration(timeout) * time.Millisecond)
+	timeLimit := newTimeLimitQuery(searchRequest.Query, deadline)
+	searchRequest.Query = timeLimit
+	return timeLimit
+}
+
+//get failed indexes of search status
+//sub index of alias named by index dir, use tag of it
+//timed out by query opt added as error of index tag
+//return timed out or not, index tag -> error
+func (f *Base) getSearchErrors(
+		tag string,
+		status *bleve.SearchStatus,
+		timeLimit *timeLimitQuery,
+	) (bool, map[string]string) {
+	var (
+		timedOut bool
+	)
+	errs := make(map[string]string)
+	if status != nil {
+		for name, err := range status.Errors {
+			errs[filepath.Base(name)] = err.Error()
+		}
+	}
+	if timeLimit != nil && timeLimit.IsTimedOut() {
+		timedOut = true
+		errs[tag] = "query timed out, hits collected before returned"
+	}
+	if len(errs) <= 0 {
+		return timedOut, nil
+	}
+	return timedOut, errs
+}
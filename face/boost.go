@@ -0,0 +1,132 @@
+package face
+
+import (
+	"context"
+	"fmt"
+	"github.com/andyzhou/tinysearch/define"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/numeric"
+	"github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/query"
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+/*
+ * face for doc boost query
+ * @author <AndyZhou>
+ * @mail <diudiu8848@163.com>
+ * - score of matched doc multiplied by index time doc boost
+ * - boost read from doc value of boost field, not need stored fields
+ * - used for score sorted query, top hits window not need
+ */
+
+//inter doc boost query
+type docBoostQuery struct {
+	query.Query
+}
+
+//inter doc boost searcher
+type docBoostSearcher struct {
+	search.Searcher
+	dvReader index.DocValueReader
+	explain  bool
+}
+
+//construct
+func newDocBoostQuery(q query.Query) *docBoostQuery {
+	this := &docBoostQuery{
+		Query: q,
+	}
+	return this
+}
+
+//validate wrapped query
+func (q *docBoostQuery) Validate() error {
+	if vq, ok := q.Query.(query.ValidatableQuery); ok {
+		return vq.Validate()
+	}
+	return nil
+}
+
+//get searcher
+func (q *docBoostQuery) Searcher(
+		ctx context.Context,
+		i index.IndexReader,
+		m mapping.IndexMapping,
+		options search.SearcherOptions,
+	) (search.Searcher, error) {
+	searcher, err := q.Query.Searcher(ctx, i, m, options)
+	if err != nil {
+		return nil, err
+	}
+	dvReader, err := i.DocValueReader([]string{define.DocFieldOfBoost})
+	if err != nil {
+		searcher.Close()
+		return nil, err
+	}
+	return &docBoostSearcher{
+		Searcher: searcher,
+		dvReader: dvReader,
+		explain: options.Explain,
+	}, nil
+}
+
+/////////////////////////////
+//api for doc boost searcher
+/////////////////////////////
+
+//get next doc
+func (s *docBoostSearcher) Next(ctx *search.SearchContext) (*search.DocumentMatch, error) {
+	dm, err := s.Searcher.Next(ctx)
+	if err != nil || dm == nil {
+		return dm, err
+	}
+	return dm, s.boost(dm)
+}
+
+//advance to doc
+func (s *docBoostSearcher) Advance(
+		ctx *search.SearchContext,
+		ID index.IndexInternalID,
+	) (*search.DocumentMatch, error) {
+	dm, err := s.Searcher.Advance(ctx, ID)
+	if err != nil || dm == nil {
+		return dm, err
+	}
+	return dm, s.boost(dm)
+}
+
+//////////////
+//private func
+//////////////
+
+//multiply score by doc boost, full precision term used
+func (s *docBoostSearcher) boost(dm *search.DocumentMatch) error {
+	var (
+		boost float64
+	)
+	err := s.dvReader.VisitDocValues(dm.IndexInternalID, func(field string, term []byte) {
+		valid, shift := numeric.ValidPrefixCodedTerm(string(term))
+		if !valid || shift != 0 {
+			return
+		}
+		if i64, subErr := numeric.PrefixCoded(term).Int64(); subErr == nil {
+			boost = numeric.Int64ToFloat64(i64)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if boost <= 0 || boost == 1 {
+		return nil
+	}
+	dm.Score *= boost
+	if s.explain && dm.Expl != nil {
+		dm.Expl = &search.Explanation{
+			Value: dm.Score,
+			Message: fmt.Sprintf("product of doc boost %v and:", boost),
+			Children: []*search.Explanation{dm.Expl},
+		}
+	}
+	return nil
+}
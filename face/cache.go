@@ -0,0 +1,207 @@
+package face
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	genJson "encoding/json"
+	"github.com/andyzhou/tinysearch/json"
+	"github.com/andyzhou/tinysearch/lib"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+/*
+ * face for query cache
+ * @author <AndyZhou>
+ * @mail <diudiu8848@163.com>
+ * - lru cache per index, keyed by normalized query opt
+ * - result cached with index write version, old version treated as missed
+ * - result cached as encoded data, decoded as new copy on get
+ * - disabled by default
+ */
+
+//inter cached item
+type queryCacheItem struct {
+	version int64
+	data    []byte //encoded result
+}
+
+//inter cache of index
+type indexQueryCache struct {
+	lru   *lib.LRU
+	stale int64 //hits of old version, counted as missed
+}
+
+//face info
+type QueryCache struct {
+	maxSize int
+	ttl     time.Duration
+	caches  map[string]*indexQueryCache //index tag -> cache
+	sync.RWMutex
+}
+
+//construct
+func NewQueryCache() *QueryCache {
+	//self init
+	this := &QueryCache{
+		caches: make(map[string]*indexQueryCache),
+	}
+	return this
+}
+
+//set size and ttl limit, old caches cleared
+//max size <= 0 means disabled
+func (f *QueryCache) SetLimit(maxSize int, ttl time.Duration) {
+	f.Lock()
+	defer f.Unlock()
+	f.maxSize = maxSize
+	f.ttl = ttl
+	f.caches = make(map[string]*indexQueryCache)
+}
+
+//check cache enabled or not
+func (f *QueryCache) IsEnabled() bool {
+	f.RLock()
+	defer f.RUnlock()
+	return f.maxSize > 0
+}
+
+//get cache key of normalized query opt
+//return empty if opt can't be encoded
+func (f *QueryCache) GetKey(opt *json.QueryOptJson) string {
+	if opt == nil {
+		return ""
+	}
+	//fields not affect result removed
+	keyOpt := *opt
+	keyOpt.Tag = ""
+	keyOpt.NoCache = false
+	optByte, err := genJson.Marshal(&keyOpt)
+	if err != nil {
+		return ""
+	}
+	sum := sha1.Sum(optByte)
+	return hex.EncodeToString(sum[:])
+}
+
+//get cached result of version
+//return copy of result, nil if not cached or old version
+func (f *QueryCache) Get(
+		tag string,
+		key string,
+		version int64,
+	) *json.SearchResultJson {
+	cache := f.getCache(tag)
+	if cache == nil || key == "" {
+		return nil
+	}
+	v, ok := cache.lru.Get(key)
+	if !ok {
+		return nil
+	}
+	item, ok := v.(*queryCacheItem)
+	if !ok || item.version != version {
+		//index changed
+		atomic.AddInt64(&cache.stale, 1)
+		cache.lru.Remove(key)
+		return nil
+	}
+	result := json.NewSearchResultJson()
+	err := result.Decode(item.data)
+	if err != nil {
+		cache.lru.Remove(key)
+		return nil
+	}
+	result.Cached = true
+	result.Timings = nil
+	return result
+}
+
+//cache result of version
+func (f *QueryCache) Set(
+		tag string,
+		key string,
+		version int64,
+		result *json.SearchResultJson,
+	) {
+	cache := f.getCache(tag)
+	if cache == nil || key == "" || result == nil {
+		return
+	}
+	//keep encoded data, avoid changed by caller
+	data, err := result.Encode()
+	if err != nil {
+		return
+	}
+	cache.lru.Set(key, &queryCacheItem{
+		version: version,
+		data: data,
+	})
+}
+
+//clear caches of indexes, all cleared if no tags
+func (f *QueryCache) Clear(tags ...string) {
+	f.Lock()
+	defer f.Unlock()
+	if len(tags) <= 0 {
+		f.caches = make(map[string]*indexQueryCache)
+		return
+	}
+	for _, tag := range tags {
+		delete(f.caches, tag)
+	}
+}
+
+//get cache stats of index
+func (f *QueryCache) GetStats(tag string) *json.QueryCacheStatsJson {
+	result := json.NewQueryCacheStatsJson()
+	result.Tag = tag
+	f.RLock()
+	result.MaxSize = f.maxSize
+	result.TTL = int(f.ttl.Seconds())
+	f.RUnlock()
+	f.RLock()
+	cache, ok := f.caches[tag]
+	f.RUnlock()
+	if !ok {
+		return result
+	}
+	stale := atomic.LoadInt64(&cache.stale)
+	result.Size = cache.lru.Len()
+	result.Hits, result.Misses, result.Evictions = cache.lru.GetStats()
+	result.Hits -= stale
+	result.Misses += stale
+	if total := result.Hits + result.Misses; total > 0 {
+		result.HitRate = float64(result.Hits) / float64(total)
+	}
+	return result
+}
+
+//////////////
+//private func
+//////////////
+
+//get or create cache of index
+//return nil if disabled
+func (f *QueryCache) getCache(tag string) *indexQueryCache {
+	f.RLock()
+	cache, ok := f.caches[tag]
+	maxSize := f.maxSize
+	f.RUnlock()
+	if ok || maxSize <= 0 {
+		return cache
+	}
+
+	//create new cache
+	f.Lock()
+	defer f.Unlock()
+	if cache, ok = f.caches[tag]; ok {
+		return cache
+	}
+	cache = &indexQueryCache{
+		lru: lib.NewLRU(f.maxSize, f.ttl),
+	}
+	f.caches[tag] = cache
+	return cache
+}
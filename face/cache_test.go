@@ -0,0 +1,106 @@
+package face
+
+import (
+	"github.com/andyzhou/tinysearch/define"
+	"github.com/andyzhou/tinysearch/json"
+	"testing"
+	"time"
+)
+
+//test cached result invalidated by doc write
+func TestQueryCacheInvalidate(t *testing.T) {
+	m := newTestManager(t)
+	query := m.GetQuery()
+	index := newTestIndex(t, m, "posts")
+	addTestDocs(t, m, index, map[string]map[string]interface{}{
+		"1": {"title": "apple"},
+	})
+	newOpt := func() *json.QueryOptJson {
+		opt := json.NewQueryOptJson()
+		opt.QueryKind = define.QueryKindOfMatchAll
+		opt.Size = 10
+		return opt
+	}
+
+	//disabled by default
+	result, err := query.Query(index, newOpt())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err = query.Query(index, newOpt())
+	if err != nil || result.Cached {
+		t.Fatal("result should not be cached by default")
+	}
+
+	//cached after enabled
+	query.SetCache(10, time.Minute)
+	result, err = query.Query(index, newOpt())
+	if err != nil || result.Cached {
+		t.Fatal("first query should not be cached")
+	}
+	result, err = query.Query(index, newOpt())
+	if err != nil || !result.Cached || result.Total != 1 {
+		t.Fatal("second query should be cached")
+	}
+
+	//changed cached copy not affect cache
+	result.Total = 100
+	result, err = query.Query(index, newOpt())
+	if err != nil || result.Total != 1 {
+		t.Fatal("cached result changed by caller")
+	}
+
+	//skip cache by opt
+	opt := newOpt()
+	opt.NoCache = true
+	result, err = query.Query(index, opt)
+	if err != nil || result.Cached {
+		t.Fatal("no cache opt should skip cache")
+	}
+
+	//invalidated by doc write
+	addTestDocs(t, m, index, map[string]map[string]interface{}{
+		"2": {"title": "pear"},
+	})
+	result, err = query.Query(index, newOpt())
+	if err != nil || result.Cached || result.Total != 2 {
+		t.Fatal("cached result should be invalidated by doc write")
+	}
+	stats := query.GetCacheStats(index.GetTag())
+	if stats.Hits != 2 || stats.Misses != 2 {
+		t.Fatalf("cache hits %v, misses %v, expect 2 and 2", stats.Hits, stats.Misses)
+	}
+
+	//cleared by tag
+	query.ClearCache(index.GetTag())
+	result, err = query.Query(index, newOpt())
+	if err != nil || result.Cached {
+		t.Fatal("cached result should be cleared")
+	}
+}
+
+//test cached results evicted by size limit
+func TestQueryCacheEvict(t *testing.T) {
+	m := newTestManager(t)
+	query := m.GetQuery()
+	query.SetCache(1, time.Minute)
+	index := newTestIndex(t, m, "posts")
+	addTestDocs(t, m, index, map[string]map[string]interface{}{
+		"1": {"title": "apple"},
+	})
+	for _, key := range []string{"apple", "pear", "apple"} {
+		opt := json.NewQueryOptJson()
+		opt.Key = key
+		result, err := query.Query(index, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Cached {
+			t.Fatalf("result of %v should be evicted", key)
+		}
+	}
+	stats := query.GetCacheStats(index.GetTag())
+	if stats.Size != 1 || stats.Evictions != 2 {
+		t.Fatalf("cache size %v, evictions %v, expect 1 and 2", stats.Size, stats.Evictions)
+	}
+}
@@ -0,0 +1,184 @@
+package face
+
+import (
+	"github.com/andyzhou/tinysearch/define"
+	"github.com/andyzhou/tinysearch/json"
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/numeric"
+	"github.com/blevesearch/bleve/v2/search"
+	"math"
+	"strconv"
+	"time"
+)
+
+/*
+ * face for field collapse
+ * @author <AndyZhou>
+ * @mail <diudiu8848@163.com>
+ * - top hits window grouped by field value, best hit of group first
+ * - field value got by extra sort key, not need stored field
+ * - total group count by terms facet of all matched docs
+ * - docs without field value grouped together
+ */
+
+//inter group of hits
+type collapseGroup struct {
+	key  string
+	hits search.DocumentMatchCollection //sorted, best hit first
+}
+
+//face info
+type Collapse struct {
+}
+
+//construct
+func NewCollapse() *Collapse {
+	//self init
+	this := &Collapse{}
+	return this
+}
+
+//get collapse opt of query
+//return nil if not need collapse
+func (f *Collapse) GetOpt(opt *json.QueryOptJson) *json.CollapseJson {
+	if opt == nil || opt.Collapse == nil || opt.Collapse.Field == "" {
+		return nil
+	}
+	return opt.Collapse
+}
+
+//prepare search request
+//top hits window fetched, field value added as last sort key
+//should be called after sort setup
+func (f *Collapse) Prepare(
+		searchRequest *bleve.SearchRequest,
+		opt *json.CollapseJson,
+		offset, size int,
+	) {
+	//fetch top hits window
+	window := opt.Window
+	if window <= 0 {
+		window = define.CollapseWindow
+	}
+	if window < offset + size {
+		window = offset + size
+	}
+	searchRequest.From = 0
+	if searchRequest.Size < window {
+		searchRequest.Size = window
+	}
+
+	//field value as last sort key, not change order
+	searchRequest.Sort = append(searchRequest.Sort, &search.SortField{
+		Field: opt.Field,
+	})
+
+	//count groups of all matched docs
+	searchRequest.AddFacet(define.InterCollapseFacet,
+		bleve.NewFacetRequest(opt.Field, math.MaxInt32))
+}
+
+//group sorted hits by field value
+//return groups of current page, total group count
+func (f *Collapse) Apply(
+		opt *json.CollapseJson,
+		searchResult *bleve.SearchResult,
+		schema map[string]string,
+		offset, size int,
+	) ([]*collapseGroup, uint64) {
+	//group hits in order, first hit is the best one
+	fieldType := schema[opt.Field]
+	groups := make([]*collapseGroup, 0)
+	groupMap := make(map[string]*collapseGroup)
+	for _, hit := range searchResult.Hits {
+		key := f.getKey(hit, fieldType)
+		group, ok := groupMap[key]
+		if !ok {
+			group = &collapseGroup{
+				key: key,
+			}
+			groupMap[key] = group
+			groups = append(groups, group)
+		}
+		if len(group.hits) < opt.InnerSize || len(group.hits) <= 0 {
+			group.hits = append(group.hits, hit)
+		}
+	}
+
+	//get total group count
+	total := f.getGroupCount(searchResult.Facets[define.InterCollapseFacet], fieldType)
+	if total < uint64(len(groups)) {
+		total = uint64(len(groups))
+	}
+
+	//pick up current page
+	if offset >= len(groups) {
+		return []*collapseGroup{}, total
+	}
+	end := offset + size
+	if end > len(groups) {
+		end = len(groups)
+	}
+	return groups[offset:end], total
+}
+
+//////////////
+//private func
+//////////////
+
+//get group key of hit from last sort key
+//number and date value decoded, empty if missing
+func (f *Collapse) getKey(
+		hit *search.DocumentMatch,
+		fieldType string,
+	) string {
+	if len(hit.Sort) <= 0 {
+		return ""
+	}
+	key := hit.Sort[len(hit.Sort)-1]
+	if key == search.HighTerm || key == search.LowTerm {
+		return ""
+	}
+	switch fieldType {
+	case define.FieldTypeOfInt, define.FieldTypeOfFloat:
+		if i64, err := numeric.PrefixCoded(key).Int64(); err == nil {
+			return strconv.FormatFloat(numeric.Int64ToFloat64(i64), 'f', -1, 64)
+		}
+	case define.FieldTypeOfDateTime:
+		if i64, err := numeric.PrefixCoded(key).Int64(); err == nil {
+			return time.Unix(0, i64).UTC().Format(time.RFC3339)
+		}
+	}
+	return key
+}
+
+//get group count of terms facet
+//only full precision terms counted for number and date
+func (f *Collapse) getGroupCount(
+		facet *search.FacetResult,
+		fieldType string,
+	) uint64 {
+	var (
+		count uint64
+	)
+	if facet == nil || facet.Terms == nil {
+		return count
+	}
+	isNumeric := fieldType == define.FieldTypeOfInt ||
+		fieldType == define.FieldTypeOfFloat ||
+		fieldType == define.FieldTypeOfDateTime
+	for _, term := range facet.Terms.Terms() {
+		if isNumeric {
+			valid, shift := numeric.ValidPrefixCodedTerm(term.Term)
+			if !valid || shift != 0 {
+				continue
+			}
+		}
+		count++
+	}
+	if facet.Missing > 0 {
+		//docs without field value
+		count++
+	}
+	return count
+}
@@ -0,0 +1,312 @@
+package face
+
+import (
+	"context"
+	"fmt"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/blevesearch/bleve/v2/search/searcher"
+	index "github.com/blevesearch/bleve_index_api"
+	"math"
+)
+
+/*
+ * face for dis max query
+ * @author <AndyZhou>
+ * @mail <diudiu8848@163.com>
+ * - score of doc is max score of matched sub queries
+ * - others matched sub queries added by tie breaker, 0 means max only
+ * - used for best fields kind of multi fields match
+ */
+
+//inter dis max query
+type disMaxQuery struct {
+	*query.DisjunctionQuery
+	tieBreaker float64
+}
+
+//inter dis max searcher
+//sub searchers merged by doc id, like disjunction searcher
+type disMaxSearcher struct {
+	searchers   []search.Searcher
+	currs       []*search.DocumentMatch //current doc of sub searchers, nil if end
+	tieBreaker  float64
+	explain     bool
+	initialized bool
+}
+
+//construct
+func newDisMaxQuery(tieBreaker float64, queries ...query.Query) *disMaxQuery {
+	if tieBreaker < 0 {
+		tieBreaker = 0
+	}
+	if tieBreaker > 1 {
+		tieBreaker = 1
+	}
+	this := &disMaxQuery{
+		DisjunctionQuery: query.NewDisjunctionQuery(queries),
+		tieBreaker: tieBreaker,
+	}
+	return this
+}
+
+//get searcher
+func (q *disMaxQuery) Searcher(
+		ctx context.Context,
+		i index.IndexReader,
+		m mapping.IndexMapping,
+		options search.SearcherOptions,
+	) (search.Searcher, error) {
+	//init sub searchers
+	searchers := make([]search.Searcher, 0, len(q.Disjuncts))
+	for _, disjunct := range q.Disjuncts {
+		subSearcher, err := disjunct.Searcher(ctx, i, m, options)
+		if err != nil {
+			for _, v := range searchers {
+				v.Close()
+			}
+			return nil, err
+		}
+		searchers = append(searchers, subSearcher)
+	}
+	if len(searchers) <= 0 {
+		return searcher.NewMatchNoneSearcher(i)
+	}
+
+	//init dis max searcher
+	s := &disMaxSearcher{
+		searchers: searchers,
+		currs: make([]*search.DocumentMatch, len(searchers)),
+		tieBreaker: q.tieBreaker,
+		explain: options.Explain,
+	}
+	s.computeQueryNorm()
+	return s, nil
+}
+
+/////////////////////////
+//api for dis max searcher
+/////////////////////////
+
+//get next doc
+func (s *disMaxSearcher) Next(ctx *search.SearchContext) (*search.DocumentMatch, error) {
+	var (
+		err error
+	)
+	if !s.initialized {
+		err = s.initSearchers(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	//get sub searchers matched min doc id
+	matching := s.getMatching()
+	if len(matching) <= 0 {
+		return nil, nil
+	}
+	dm := s.score(matching)
+
+	//move matched sub searchers forward
+	for _, idx := range matching {
+		if s.currs[idx] != dm {
+			ctx.DocumentMatchPool.Put(s.currs[idx])
+		}
+		s.currs[idx], err = s.searchers[idx].Next(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return dm, nil
+}
+
+//advance to doc
+func (s *disMaxSearcher) Advance(
+		ctx *search.SearchContext,
+		ID index.IndexInternalID,
+	) (*search.DocumentMatch, error) {
+	var (
+		err error
+	)
+	if !s.initialized {
+		err = s.initSearchers(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for idx, v := range s.searchers {
+		if s.currs[idx] != nil {
+			if s.currs[idx].IndexInternalID.Compare(ID) >= 0 {
+				continue
+			}
+			ctx.DocumentMatchPool.Put(s.currs[idx])
+		}
+		s.currs[idx], err = v.Advance(ctx, ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s.Next(ctx)
+}
+
+//close sub searchers
+func (s *disMaxSearcher) Close() error {
+	var (
+		err error
+	)
+	for _, v := range s.searchers {
+		subErr := v.Close()
+		if subErr != nil && err == nil {
+			err = subErr
+		}
+	}
+	return err
+}
+
+//get weight, sum of sub searchers
+func (s *disMaxSearcher) Weight() float64 {
+	var (
+		weight float64
+	)
+	for _, v := range s.searchers {
+		weight += v.Weight()
+	}
+	return weight
+}
+
+//set query norm of sub searchers
+func (s *disMaxSearcher) SetQueryNorm(norm float64) {
+	for _, v := range s.searchers {
+		v.SetQueryNorm(norm)
+	}
+}
+
+//get worst case count
+func (s *disMaxSearcher) Count() uint64 {
+	var (
+		count uint64
+	)
+	for _, v := range s.searchers {
+		count += v.Count()
+	}
+	return count
+}
+
+//get min matched sub searchers
+func (s *disMaxSearcher) Min() int {
+	return 0
+}
+
+//get memory size
+func (s *disMaxSearcher) Size() int {
+	size := 0
+	for _, v := range s.searchers {
+		size += v.Size()
+	}
+	for _, v := range s.currs {
+		if v != nil {
+			size += v.Size()
+		}
+	}
+	return size
+}
+
+//get doc match pool size
+func (s *disMaxSearcher) DocumentMatchPoolSize() int {
+	size := len(s.currs)
+	for _, v := range s.searchers {
+		size += v.DocumentMatchPoolSize()
+	}
+	return size
+}
+
+//////////////
+//private func
+//////////////
+
+//compute query norm like disjunction searcher
+func (s *disMaxSearcher) computeQueryNorm() {
+	weight := s.Weight()
+	if weight <= 0 {
+		return
+	}
+	s.SetQueryNorm(1.0 / math.Sqrt(weight))
+}
+
+//get first doc of all sub searchers
+func (s *disMaxSearcher) initSearchers(ctx *search.SearchContext) error {
+	var (
+		err error
+	)
+	for idx, v := range s.searchers {
+		if s.currs[idx] != nil {
+			ctx.DocumentMatchPool.Put(s.currs[idx])
+		}
+		s.currs[idx], err = v.Next(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	s.initialized = true
+	return nil
+}
+
+//get index of sub searchers at min doc id
+func (s *disMaxSearcher) getMatching() []int {
+	matching := make([]int, 0, len(s.currs))
+	for idx, curr := range s.currs {
+		if curr == nil {
+			continue
+		}
+		if len(matching) > 0 {
+			cmp := curr.IndexInternalID.Compare(s.currs[matching[0]].IndexInternalID)
+			if cmp > 0 {
+				continue
+			}
+			if cmp < 0 {
+				matching = matching[:0]
+			}
+		}
+		matching = append(matching, idx)
+	}
+	return matching
+}
+
+//score doc as max score of matched sub searchers
+//others added by tie breaker, first matched doc reused as result
+func (s *disMaxSearcher) score(matching []int) *search.DocumentMatch {
+	var (
+		maxScore, sumScore float64
+	)
+	others := make([]*search.DocumentMatch, 0, len(matching))
+	for _, idx := range matching {
+		curr := s.currs[idx]
+		sumScore += curr.Score
+		if curr.Score > maxScore {
+			maxScore = curr.Score
+		}
+		if idx != matching[0] {
+			others = append(others, curr)
+		}
+	}
+	score := maxScore + s.tieBreaker * (sumScore - maxScore)
+
+	//explain of sub searchers
+	dm := s.currs[matching[0]]
+	if s.explain {
+		children := make([]*search.Explanation, 0, len(matching))
+		for _, idx := range matching {
+			children = append(children, s.currs[idx].Expl)
+		}
+		dm.Expl = &search.Explanation{
+			Value: score,
+			Message: fmt.Sprintf("max plus %v times others of:", s.tieBreaker),
+			Children: children,
+		}
+	}
+	dm.Score = score
+	dm.PartialMatch = len(matching) != len(s.searchers)
+	dm.FieldTermLocations = search.MergeFieldTermLocations(dm.FieldTermLocations, others)
+	return dm
+}
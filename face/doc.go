@@ -1,9 +1,13 @@
 package face
 
 import (
+	genJson "encoding/json"
 	"errors"
+	"fmt"
+	"github.com/andyzhou/tinysearch/define"
 	"github.com/andyzhou/tinysearch/iface"
 	"github.com/andyzhou/tinysearch/json"
+	"log"
 )
 
 /*
@@ -15,6 +19,7 @@ import (
 //face info
 type Doc struct {
 	hookForAddDoc func(jsonByte []byte) error
+	percolator    iface.IPercolator //optional, run saved queries on new doc
 	Base
 }
 
@@ -39,7 +44,8 @@ func (f *Doc) GetCount(
 	}
 
 	//get indexer
-	indexer := index.GetIndex()
+	indexer := index.AcquireIndex()
+	defer index.ReleaseIndex()
 	if indexer == nil {
 		return count, errors.New("cant' get index")
 	}
@@ -67,7 +73,8 @@ func (f *Doc) RemoveDocs(
 	}
 
 	//get indexer
-	indexer := index.GetIndex()
+	indexer := index.AcquireIndex()
+	defer index.ReleaseIndex()
 	if indexer == nil {
 		return errors.New("cant' get index")
 	}
@@ -76,6 +83,7 @@ func (f *Doc) RemoveDocs(
 	for _, docId := range docIds {
 		err = indexer.Delete(docId)
 	}
+	index.IncVersion()
 	return err
 }
 
@@ -90,7 +98,8 @@ func (f *Doc) RemoveDoc(
 	}
 
 	//get indexer
-	indexer := index.GetIndex()
+	indexer := index.AcquireIndex()
+	defer index.ReleaseIndex()
 	if indexer == nil {
 		return errors.New("cant' get index")
 	}
@@ -100,6 +109,7 @@ func (f *Doc) RemoveDoc(
 	if err != nil {
 		return err
 	}
+	index.IncVersion()
 	return nil
 }
 
@@ -114,19 +124,21 @@ func (f *Doc) GetDocs(
 	}
 
 	//get indexer
-	indexer := index.GetIndex()
+	indexer := index.AcquireIndex()
+	defer index.ReleaseIndex()
 	if indexer == nil {
 		return nil, errors.New("cant' get index")
 	}
 
 	//get batch doc by ids
 	result := make(map[string]*json.HitDocJson)
+	schema := index.GetSchema()
 	for _, docId := range docIds {
 		doc, err := indexer.Document(docId)
 		if err != nil || doc == nil {
 			continue
 		}
-		hitJson, err := f.AnalyzeDoc(doc, nil)
+		hitJson, err := f.AnalyzeDoc(doc, nil, schema)
 		if err != nil || hitJson == nil {
 			continue
 		}
@@ -146,7 +158,8 @@ func (f *Doc) GetDoc(
 	}
 
 	//get indexer
-	indexer := index.GetIndex()
+	indexer := index.AcquireIndex()
+	defer index.ReleaseIndex()
 	if indexer == nil {
 		return nil, errors.New("cant' get index")
 	}
@@ -161,32 +174,85 @@ func (f *Doc) GetDoc(
 	}
 
 	//analyze doc
-	return f.AnalyzeDoc(doc, nil)
+	return f.AnalyzeDoc(doc, nil, index.GetSchema())
 }
 
 //add new doc
+//json obj can be struct, kv map or json byte
+//boost used for rank doc higher in every query, default 1.0
 func (f *Doc) AddDoc(
 		index iface.IIndex,
 		docId string,
 		jsonObj interface{},
+		boosts ...float64,
 	) error {
 	var (
+		boost float64
 		err error
 	)
 	//basic check
 	if index == nil || docId == "" || jsonObj == nil {
 		return errors.New("invalid parameter")
 	}
+	if boosts != nil && len(boosts) > 0 {
+		boost = boosts[0]
+	}
 
 	//get indexer
-	indexer := index.GetIndex()
+	indexer := index.AcquireIndex()
+	defer index.ReleaseIndex()
 	if indexer == nil {
 		return errors.New("cant' get index")
 	}
 
+	//format doc as kv map
+	kvMap, source, err := f.formatDocMap(jsonObj)
+	if err != nil {
+		return err
+	}
+
+	//convert geo json points, original doc json not changed
+	f.FormatGeoJson(kvMap)
+
+	//check and set doc boost
+	boost, err = f.formatBoost(kvMap, boost)
+	if err != nil {
+		return err
+	}
+	if boost > 0 {
+		//mark index has boosted docs
+		err = indexer.SetInternal([]byte(define.InterKeyOfBoosted), []byte("1"))
+		if err != nil {
+			return err
+		}
+	}
+
+	//sync field type schema
+	err = index.MergeSchema(f.GetDocSchema(source))
+	if err != nil {
+		return err
+	}
+
+	//keep original doc json
+	if f.HasSourceField(indexer) {
+		kvMap[define.DocFieldOfSource] = string(source)
+	}
+
 	//add or update doc
-	err = indexer.Index(docId, jsonObj)
-	return err
+	err = indexer.Index(docId, kvMap)
+	if err != nil {
+		return err
+	}
+	index.IncVersion()
+
+	//percolate new doc in background, failed not affect adding
+	if f.percolator != nil {
+		err = f.percolator.Percolate(index, docId, kvMap)
+		if err != nil {
+			log.Printf("Doc:AddDoc percolate doc %v failed, err:%v\n", docId, err)
+		}
+	}
+	return nil
 }
 
 //get hook for add doc
@@ -205,4 +271,64 @@ func (f *Doc) SetHookForAddDoc(
 	}
 	f.hookForAddDoc = hook
 	return nil
-}
\ No newline at end of file
+}
+
+//set percolator
+//saved queries of index will be run on new doc
+func (f *Doc) SetPercolator(percolator iface.IPercolator) {
+	f.percolator = percolator
+}
+
+//////////////
+//private func
+//////////////
+
+//format doc as kv map
+//return kv map, original json byte
+func (f *Doc) formatDocMap(
+		jsonObj interface{},
+	) (map[string]interface{}, []byte, error) {
+	var (
+		source []byte
+		err error
+	)
+	//get original json byte
+	switch v := jsonObj.(type) {
+	case []byte:
+		source = v
+	default:
+		source, err = genJson.Marshal(jsonObj)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	//decode as kv map
+	kvMap := make(map[string]interface{})
+	err = genJson.Unmarshal(source, &kvMap)
+	if err != nil {
+		return nil, nil, err
+	}
+	return kvMap, source, nil
+}
+
+//format doc boost
+//boost from kv map used if not assigned, like doc sync from rpc
+func (f *Doc) formatBoost(
+		kvMap map[string]interface{},
+		boost float64,
+	) (float64, error) {
+	if boost > 0 {
+		kvMap[define.DocFieldOfBoost] = boost
+		return boost, nil
+	}
+	v, ok := kvMap[define.DocFieldOfBoost]
+	if !ok {
+		return 0, nil
+	}
+	boost, ok = v.(float64)
+	if !ok || boost <= 0 {
+		return 0, fmt.Errorf("invalid doc boost `%v`", v)
+	}
+	return boost, nil
+}
@@ -0,0 +1,153 @@
+package face
+
+import (
+	"context"
+	"github.com/andyzhou/tinysearch/define"
+	"github.com/andyzhou/tinysearch/json"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/numeric"
+	"github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/searcher"
+	index "github.com/blevesearch/bleve_index_api"
+	"strings"
+)
+
+/*
+ * face for exists query
+ * @author <AndyZhou>
+ * @mail <diudiu8848@163.com>
+ * - match docs which has any indexed term of field
+ * - work on text, numeric, datetime, boolean and geo fields
+ * - all terms of field dict read, cost grows with distinct values of field
+ * - only full precision terms used for numeric, datetime and geo fields
+ * - field type got by saved schema of index, then mapping
+ * - all terms used if type unknown, same matched docs with more cost
+ */
+
+//inter exists query
+type existsQuery struct {
+	field string
+}
+
+//construct
+func newExistsQuery(field string) *existsQuery {
+	this := &existsQuery{
+		field: field,
+	}
+	return this
+}
+
+//get searcher
+//all terms of field dict matched, shifted terms of prefix coded field skipped
+func (q *existsQuery) Searcher(
+		ctx context.Context,
+		i index.IndexReader,
+		m mapping.IndexMapping,
+		options search.SearcherOptions,
+	) (search.Searcher, error) {
+	terms, err := q.getTerms(i, q.isPrefixCoded(i, m))
+	if err != nil {
+		return nil, err
+	}
+	if len(terms) <= 0 {
+		return searcher.NewMatchNoneSearcher(i)
+	}
+	return searcher.NewMultiTermSearcher(ctx, i, terms, q.field, 1.0, options, false)
+}
+
+//////////////
+//private func
+//////////////
+
+//get terms of field dict
+//only full precision terms returned if field is prefix coded
+func (q *existsQuery) getTerms(
+		i index.IndexReader,
+		isPrefixCoded bool,
+	) ([]string, error) {
+	fieldDict, err := i.FieldDict(q.field)
+	if err != nil {
+		return nil, err
+	}
+	defer fieldDict.Close()
+
+	//read all terms
+	terms := make([]string, 0)
+	entry, err := fieldDict.Next()
+	for err == nil && entry != nil {
+		if isPrefixCoded {
+			valid, shift := numeric.ValidPrefixCodedTerm(entry.Term)
+			if valid && shift == 0 {
+				terms = append(terms, entry.Term)
+			}
+		}else{
+			terms = append(terms, entry.Term)
+		}
+		entry, err = fieldDict.Next()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return terms, nil
+}
+
+//check field is prefix coded or not
+//like numeric, datetime and geo field
+func (q *existsQuery) isPrefixCoded(
+		i index.IndexReader,
+		m mapping.IndexMapping,
+	) bool {
+	fieldType := q.getSchemaType(i)
+	if fieldType == "" {
+		fieldType = q.getMappingType(m)
+	}
+	switch fieldType {
+	case define.FieldTypeOfInt, define.FieldTypeOfFloat,
+		define.FieldTypeOfDateTime, define.FieldTypeOfGeoPoint:
+		return true
+	}
+	return false
+}
+
+//get field type of schema saved in index
+func (q *existsQuery) getSchemaType(i index.IndexReader) string {
+	schemaByte, err := i.GetInternal([]byte(define.InterKeyOfSchema))
+	if err != nil || schemaByte == nil {
+		return ""
+	}
+	schemaJson := json.NewIndexSchemaJson()
+	if schemaJson.Decode(schemaByte) != nil {
+		return ""
+	}
+	return schemaJson.Fields[q.field]
+}
+
+//get field type of declared mapping
+func (q *existsQuery) getMappingType(m mapping.IndexMapping) string {
+	impl, ok := m.(*mapping.IndexMappingImpl)
+	if !ok || impl == nil {
+		return ""
+	}
+
+	//find document mapping of field path
+	dm := impl.DefaultMapping
+	paths := strings.Split(q.field, ".")
+	for _, v := range paths {
+		if dm == nil {
+			return ""
+		}
+		dm = dm.Properties[v]
+	}
+	if dm == nil || len(dm.Fields) <= 0 {
+		return ""
+	}
+	switch dm.Fields[0].Type {
+	case "number":
+		return define.FieldTypeOfFloat
+	case "datetime":
+		return define.FieldTypeOfDateTime
+	case "geopoint":
+		return define.FieldTypeOfGeoPoint
+	}
+	return ""
+}
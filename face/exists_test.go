@@ -0,0 +1,52 @@
+package face
+
+import (
+	"github.com/andyzhou/tinysearch/define"
+	"github.com/andyzhou/tinysearch/json"
+	"sort"
+	"testing"
+)
+
+//test exists and missing filter of diff field types
+func TestExistsFilter(t *testing.T) {
+	m := newTestManager(t)
+	index := newTestIndex(t, m, "posts")
+	addTestDocs(t, m, index, map[string]map[string]interface{}{
+		"1": {"title": "apple", "code": "12345678", "price": 10, "createAt": "2024-01-01T00:00:00Z"},
+		"2": {"title": "pear", "code": "10000000", "price": 123456789},
+		"3": {"name": "none"},
+	})
+	tests := []struct {
+		field   string
+		exists  []string
+		missing []string
+	}{
+		{"title", []string{"1", "2"}, []string{"3"}},
+		{"code", []string{"1", "2"}, []string{"3"}},
+		{"price", []string{"1", "2"}, []string{"3"}},
+		{"createAt", []string{"1"}, []string{"2", "3"}},
+		{"unknown", []string{}, []string{"1", "2", "3"}},
+	}
+	for _, v := range tests {
+		for kind, expect := range map[int][]string{
+			define.FilterKindExists:  v.exists,
+			define.FilterKindMissing: v.missing,
+		} {
+			opt := json.NewQueryOptJson()
+			opt.QueryKind = define.QueryKindOfMatchAll
+			opt.Filters = []*json.FilterField{
+				{Kind: kind, Field: v.field, IsMust: true},
+			}
+			ids := runTestQuery(t, m, index, opt)
+			sort.Strings(ids)
+			if len(ids) != len(expect) {
+				t.Fatalf("kind %v of %v hits %v, expect %v", kind, v.field, ids, expect)
+			}
+			for idx, id := range ids {
+				if id != expect[idx] {
+					t.Fatalf("kind %v of %v hits %v, expect %v", kind, v.field, ids, expect)
+				}
+			}
+		}
+	}
+}
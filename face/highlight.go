@@ -0,0 +1,208 @@
+package face
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andyzhou/tinysearch/define"
+	"github.com/andyzhou/tinysearch/json"
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/highlight"
+	"github.com/blevesearch/bleve/v2/search/highlight/format/ansi"
+	"github.com/blevesearch/bleve/v2/search/highlight/format/html"
+	"github.com/blevesearch/bleve/v2/search/highlight/fragmenter/simple"
+	simpleHighlighter "github.com/blevesearch/bleve/v2/search/highlight/highlighter/simple"
+	"strings"
+)
+
+/*
+ * face for high light
+ * @author <AndyZhou>
+ * @mail <diudiu8848@163.com>
+ * - fragments created on current page hits only
+ * - fragment size count by chars, not bytes, fit for chinese text
+ * - overlapped terms of jieba search mode merged into one mark
+ */
+
+//face info
+type HighLight struct {
+	Base
+}
+
+//construct
+func NewHighLight() *HighLight {
+	//self init
+	this := &HighLight{}
+	return this
+}
+
+//get high light opt of query
+//return nil if not need high light
+func (f *HighLight) GetOpt(opt *json.QueryOptJson) *json.HighLightJson {
+	if opt == nil {
+		return nil
+	}
+	if opt.HighLightOpt != nil {
+		return opt.HighLightOpt
+	}
+	if opt.HighLight {
+		return json.NewHighLightJson()
+	}
+	return nil
+}
+
+//create fragments of hits
+//hits should be searched with term locations
+func (f *HighLight) Apply(
+		indexer bleve.Index,
+		opt *json.HighLightJson,
+		hits search.DocumentMatchCollection,
+	) error {
+	//basic check
+	if indexer == nil || opt == nil {
+		return errors.New("invalid parameter")
+	}
+
+	//create high lighter
+	highlighter, err := f.createHighlighter(opt)
+	if err != nil {
+		return err
+	}
+	fragmentNum := opt.FragmentNum
+	if fragmentNum <= 0 {
+		fragmentNum = define.HighLightFragmentNum
+	}
+
+	//create fragments of hit one by one
+	for _, hit := range hits {
+		if len(hit.Locations) <= 0 {
+			continue
+		}
+		doc, subErr := indexer.Document(hit.ID)
+		if subErr != nil {
+			return subErr
+		}
+		if doc == nil {
+			continue
+		}
+		fields := opt.Fields
+		if len(fields) <= 0 {
+			//all matched fields
+			fields = make([]string, 0, len(hit.Locations))
+			for field := range hit.Locations {
+				fields = append(fields, field)
+			}
+		}
+		for _, field := range fields {
+			if tlm, ok := hit.Locations[field]; ok {
+				hit.Locations[field] = f.mergeLocations(tlm)
+			}
+			highlighter.BestFragmentsInField(hit, doc, field, fragmentNum)
+		}
+	}
+	return nil
+}
+
+//format fragments into hit doc json
+func (f *HighLight) Format(
+		hitDocJson *json.HitDocJson,
+		fragments search.FieldFragmentMap,
+		opt *json.HighLightJson,
+	) {
+	//basic check
+	if hitDocJson == nil || opt == nil {
+		return
+	}
+
+	//reset and fill high lights
+	hitDocJson.HighLights = make(map[string]string)
+	for field, v := range fragments {
+		if opt.AsArray {
+			hitDocJson.AddHighLightList(field, v)
+		}else{
+			hitDocJson.AddHighLight(field, strings.Join(v, opt.Separator))
+		}
+	}
+}
+
+//////////////
+//private func
+//////////////
+
+//merge overlapped term locations of field
+//jieba search mode creates overlapped tokens, like '交换机', '交换' and '换机',
+//bleve only merge them partly, which cause broken marks.
+func (f *HighLight) mergeLocations(
+	tlm search.TermLocationMap) search.TermLocationMap {
+	var (
+		last *highlight.TermLocation
+	)
+	result := make(search.TermLocationMap)
+	addLocation := func(tl *highlight.TermLocation) {
+		result.AddLocation(tl.Term, &search.Location{
+			Pos: uint64(tl.Pos),
+			Start: uint64(tl.Start),
+			End: uint64(tl.End),
+			ArrayPositions: tl.ArrayPositions,
+		})
+	}
+
+	//locations ordered by array positions and start
+	for _, tl := range highlight.OrderTermLocations(tlm) {
+		if last != nil && last.Overlaps(tl) {
+			if tl.End > last.End {
+				last.End = tl.End
+			}
+			continue
+		}
+		if last != nil {
+			addLocation(last)
+		}
+		last = tl
+	}
+	if last != nil {
+		addLocation(last)
+	}
+	return result
+}
+
+//create high lighter by opt
+func (f *HighLight) createHighlighter(
+	opt *json.HighLightJson) (highlight.Highlighter, error) {
+	var (
+		formatter highlight.FragmentFormatter
+	)
+	//init fragment formatter by style
+	switch opt.Style {
+	case "", define.HighLightStyleOfHtml:
+		{
+			preTag, postTag := opt.PreTag, opt.PostTag
+			if preTag == "" && postTag == "" {
+				preTag, postTag = "<mark>", "</mark>"
+			}
+			formatter = html.NewFragmentFormatter(preTag, postTag)
+		}
+	case define.HighLightStyleOfAnsi:
+		{
+			color := opt.PreTag
+			if color == "" {
+				color = ansi.DefaultAnsiHighlight
+			}
+			formatter = ansi.NewFragmentFormatter(color)
+		}
+	default:
+		return nil, fmt.Errorf("invalid high light style `%s`", opt.Style)
+	}
+
+	//init fragmenter
+	fragmentSize := opt.FragmentSize
+	if fragmentSize <= 0 {
+		fragmentSize = define.HighLightFragmentSize
+	}
+	highlighter := simpleHighlighter.NewHighlighter(
+			simple.NewFragmenter(fragmentSize),
+			formatter,
+			simpleHighlighter.DefaultSeparator,
+		)
+	return highlighter, nil
+}
@@ -1,15 +1,21 @@
 package face
 
 import (
+	genJson "encoding/json"
 	"errors"
 	"fmt"
 	"github.com/andyzhou/tinysearch/define"
+	"github.com/andyzhou/tinysearch/json"
 	_ "github.com/andyzhou/tinysearch/jiebago/tokenizers" //for init tokenizers
 	"github.com/blevesearch/bleve/v2"
 	_ "github.com/blevesearch/bleve/v2/analysis/analyzer/custom" //for init 'custom'
 	"github.com/blevesearch/bleve/v2/mapping"
+	"log"
 	"os"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 /*
@@ -17,14 +23,28 @@ import (
  * @author <AndyZhou>
  * @mail <diudiu8848@163.com>
  * - chinese token base on 'github.com/wangbin/jiebago'
+ * - unloaded index will be reopened on next `GetIndex`
+ * - acquired index can't be unloaded until released
  */
 
 //face info
 type Index struct {
-	indexDir string
-	dictFile string
-	tag      string
-	indexer  bleve.Index
+	indexDir     string
+	dictFile     string
+	tag          string
+	indexer      bleve.Index
+	indexMapping *mapping.IndexMappingImpl //optional, setup by template
+	setting      *json.IndexSettingJson    //optional, setup by template
+	isUnloaded   bool                      //closed by idle check, can be reopened
+	activeAt     int64                     //last active time, unix nano
+	cbForOpen    func(tag string)          //called after index opened
+	schema       map[string]string         //field path -> field type
+	declared     map[string]string         //declared by template, can't be changed
+	schemaLoaded bool
+	synonyms     *json.SynonymSetJson   //query time synonym set, nil means not loaded
+	percolates   *json.PercolateSetJson //saved queries for percolate, nil means not loaded
+	version      int64                  //write version, used for query cache
+	refs         int32                  //acquired count, can't be unloaded if > 0
 	sync.RWMutex
 }
 
@@ -41,27 +61,393 @@ func NewIndex(indexDir, tag string, dictFile ...string) *Index {
 		indexDir:indexDir,
 		dictFile: dictFilePath,
 		tag:tag,
+		activeAt: time.Now().UnixNano(),
+		version: time.Now().UnixNano(),
 	}
 	return this
 }
 
-//remove index
+//close index
+func (f *Index) Close() error {
+	f.Lock()
+	defer f.Unlock()
+	f.isUnloaded = false
+	if f.indexer == nil {
+		return nil
+	}
+	err := f.indexer.Close()
+	f.indexer = nil
+	return err
+}
+
+//unload index, will be reopened on next `GetIndex`
+//skipped if index acquired by others
+func (f *Index) Unload() error {
+	f.Lock()
+	defer f.Unlock()
+	if f.indexer == nil || atomic.LoadInt32(&f.refs) > 0 {
+		return nil
+	}
+	err := f.indexer.Close()
+	f.indexer = nil
+	f.isUnloaded = true
+	return err
+}
+
+//check index is opened or not
+func (f *Index) IsOpened() bool {
+	f.RLock()
+	defer f.RUnlock()
+	return f.indexer != nil
+}
+
+//get last active time
+func (f *Index) GetActiveTime() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&f.activeAt))
+}
+
+//set callback for index opened
+func (f *Index) SetOpenCallback(cb func(tag string)) {
+	f.cbForOpen = cb
+}
+
+//remove index, include data files
 func (f *Index) RemoveIndex() error {
 	//basic check
 	if f.tag == "" {
 		return errors.New("invalid tag")
 	}
-	err := os.RemoveAll(f.indexDir)
+
+	//close indexer first
+	f.Close()
+
+	//remove sub dir of index
+	subDir := fmt.Sprintf("%s/%s", f.indexDir, f.tag)
+	err := os.RemoveAll(subDir)
 	return err
 }
 
+//get index tag
+func (f *Index) GetTag() string {
+	return f.tag
+}
+
+//apply index template, should be called before `CreateIndex`
+func (f *Index) ApplyTemplate(tpl *json.IndexTemplateJson) error {
+	var (
+		indexMapping *mapping.IndexMappingImpl
+		err error
+	)
+	//basic check
+	if tpl == nil {
+		return errors.New("invalid parameter")
+	}
+
+	//init index mapping
+	if tpl.Mapping != nil && len(tpl.Mapping) > 0 {
+		indexMapping = mapping.NewIndexMapping()
+		err = genJson.Unmarshal(tpl.Mapping, indexMapping)
+		if err != nil {
+			return fmt.Errorf("invalid mapping of template %v, err:%v", tpl.Name, err)
+		}
+	}else{
+		indexMapping = mapping.NewIndexMapping()
+	}
+
+	//set default analyzer
+	switch tpl.Analyzer {
+	case "":
+		{
+			//use chinese analyzer if dict file setup
+			if f.dictFile != "" && (tpl.Mapping == nil || len(tpl.Mapping) <= 0) {
+				err = f.addChineseAnalyzer(indexMapping, f.dictFile)
+				if err != nil {
+					return err
+				}
+				indexMapping.DefaultAnalyzer = define.CustomTokenizerOfJieBa
+			}
+		}
+	case define.CustomTokenizerOfJieBa:
+		{
+			err = f.addChineseAnalyzer(indexMapping, f.dictFile)
+			if err != nil {
+				return err
+			}
+			indexMapping.DefaultAnalyzer = define.CustomTokenizerOfJieBa
+		}
+	default:
+		indexMapping.DefaultAnalyzer = tpl.Analyzer
+	}
+
+	//add geo point field mapping declared by schema
+	f.addGeoPointMapping(indexMapping, tpl.Schema)
+
+	//validate mapping
+	err = indexMapping.Validate()
+	if err != nil {
+		return err
+	}
+
+	//get declared schema from mapping and template
+	declared := make(map[string]string)
+	f.getMappingSchema(indexMapping.DefaultMapping, "", declared)
+	for k, v := range tpl.Schema {
+		declared[k] = v
+	}
+
+	//sync into index
+	f.Lock()
+	defer f.Unlock()
+	f.indexMapping = indexMapping
+	f.setting = tpl.Settings
+	f.declared = declared
+	return nil
+}
+
+//get field type schema
+func (f *Index) GetSchema() map[string]string {
+	//make sure saved schema can be loaded
+	f.AcquireIndex()
+	defer f.ReleaseIndex()
+
+	f.Lock()
+	defer f.Unlock()
+	f.loadSchema()
+	result := make(map[string]string, len(f.schema))
+	for k, v := range f.schema {
+		result[k] = v
+	}
+	return result
+}
+
+//merge field types into schema
+//declared field type can't be changed, int field can be widened as float
+func (f *Index) MergeSchema(fields map[string]string) error {
+	var (
+		isChanged bool
+	)
+	//basic check
+	if fields == nil || len(fields) <= 0 {
+		return nil
+	}
+
+	//make sure index opened, merged schema can be saved
+	f.AcquireIndex()
+	defer f.ReleaseIndex()
+
+	//merge with locker
+	f.Lock()
+	defer f.Unlock()
+	if f.indexer == nil {
+		return errors.New("can't get indexer")
+	}
+	f.loadSchema()
+	for k, v := range fields {
+		if _, ok := f.declared[k]; ok {
+			continue
+		}
+		oldType, ok := f.schema[k]
+		if !ok {
+			f.schema[k] = v
+			isChanged = true
+			continue
+		}
+		if oldType == define.FieldTypeOfInt && v == define.FieldTypeOfFloat {
+			f.schema[k] = v
+			isChanged = true
+		}
+	}
+	if !isChanged {
+		return nil
+	}
+
+	//save into index
+	schemaJson := json.NewIndexSchemaJson()
+	for k, v := range f.schema {
+		if _, ok := f.declared[k]; !ok {
+			schemaJson.Fields[k] = v
+		}
+	}
+	schemaByte, err := schemaJson.Encode()
+	if err != nil {
+		return err
+	}
+	return f.indexer.SetInternal([]byte(define.InterKeyOfSchema), schemaByte)
+}
+
+//get write version
+//init by create time, differ from dropped index with same tag
+func (f *Index) GetVersion() int64 {
+	return atomic.LoadInt64(&f.version)
+}
+
+//increase write version, called after docs changed
+func (f *Index) IncVersion() {
+	atomic.AddInt64(&f.version, 1)
+}
+
+//get synonym set, loaded from index at first time
+func (f *Index) GetSynonyms() *json.SynonymSetJson {
+	//get loaded synonyms
+	f.RLock()
+	synonyms := f.synonyms
+	f.RUnlock()
+	if synonyms != nil {
+		return synonyms
+	}
+
+	//load from index
+	synonyms = json.NewSynonymSetJson()
+	indexer := f.AcquireIndex()
+	defer f.ReleaseIndex()
+	if indexer == nil {
+		return synonyms
+	}
+	synonymByte, err := indexer.GetInternal([]byte(define.InterKeyOfSynonym))
+	if err == nil && synonymByte != nil {
+		if synonyms.Decode(synonymByte) != nil {
+			synonyms = json.NewSynonymSetJson()
+		}
+	}
+
+	//sync into index
+	f.Lock()
+	defer f.Unlock()
+	f.synonyms = synonyms
+	return synonyms
+}
+
+//set synonym set, replace old
+//used for query time expansion, not need reindex
+func (f *Index) SetSynonyms(synonyms *json.SynonymSetJson) error {
+	//basic check
+	if synonyms == nil {
+		return errors.New("invalid parameter")
+	}
+	indexer := f.AcquireIndex()
+	defer f.ReleaseIndex()
+	if indexer == nil {
+		return errors.New("can't get indexer")
+	}
+
+	//save into index
+	synonymByte, err := synonyms.Encode()
+	if err != nil {
+		return err
+	}
+	err = indexer.SetInternal([]byte(define.InterKeyOfSynonym), synonymByte)
+	if err != nil {
+		return err
+	}
+
+	//sync into index
+	f.Lock()
+	defer f.Unlock()
+	f.synonyms = synonyms
+	f.IncVersion()
+	return nil
+}
+
+//get saved query set for percolate, loaded from index at first time
+func (f *Index) GetPercolates() *json.PercolateSetJson {
+	//get loaded percolates
+	f.RLock()
+	percolates := f.percolates
+	f.RUnlock()
+	if percolates != nil {
+		return percolates
+	}
+
+	//load from index
+	percolates = json.NewPercolateSetJson()
+	indexer := f.AcquireIndex()
+	defer f.ReleaseIndex()
+	if indexer == nil {
+		return percolates
+	}
+	percolateByte, err := indexer.GetInternal([]byte(define.InterKeyOfPercolate))
+	if err == nil && percolateByte != nil {
+		if percolates.Decode(percolateByte) != nil {
+			percolates = json.NewPercolateSetJson()
+		}
+	}
+
+	//sync into index
+	f.Lock()
+	defer f.Unlock()
+	f.percolates = percolates
+	return percolates
+}
+
+//set saved query set for percolate, replace old
+//the set should not be changed after setup, used by running percolate
+func (f *Index) SetPercolates(percolates *json.PercolateSetJson) error {
+	//basic check
+	if percolates == nil {
+		return errors.New("invalid parameter")
+	}
+	indexer := f.AcquireIndex()
+	defer f.ReleaseIndex()
+	if indexer == nil {
+		return errors.New("can't get indexer")
+	}
+
+	//save into index
+	percolateByte, err := percolates.Encode()
+	if err != nil {
+		return err
+	}
+	err = indexer.SetInternal([]byte(define.InterKeyOfPercolate), percolateByte)
+	if err != nil {
+		return err
+	}
+
+	//sync into index
+	f.Lock()
+	defer f.Unlock()
+	f.percolates = percolates
+	return nil
+}
+
 //get index
+//if index unloaded, reopen it.
 func (f *Index) GetIndex() bleve.Index {
 	//basic check
-	if f.tag == "" || f.indexer == nil {
+	if f.tag == "" {
 		return nil
 	}
-	return f.indexer
+
+	//update active time
+	atomic.StoreInt64(&f.activeAt, time.Now().UnixNano())
+
+	//get opened indexer
+	f.RLock()
+	indexer := f.indexer
+	isUnloaded := f.isUnloaded
+	f.RUnlock()
+	if indexer != nil || !isUnloaded {
+		return indexer
+	}
+
+	//reopen unloaded index
+	indexer, err := f.reopen()
+	if err != nil {
+		log.Printf("tinysearch.Index:GetIndex reopen %v failed, err:%v", f.tag, err)
+		return nil
+	}
+	return indexer
+}
+
+//acquire index for using, reopen if unloaded
+//should call `ReleaseIndex` after used, even if nil returned
+func (f *Index) AcquireIndex() bleve.Index {
+	atomic.AddInt32(&f.refs, 1)
+	return f.GetIndex()
+}
+
+//release acquired index
+func (f *Index) ReleaseIndex() {
+	atomic.AddInt32(&f.refs, -1)
 }
 
 //create index
@@ -76,7 +462,10 @@ func (f *Index) CreateIndex() error {
 		return errors.New("invalid parameter")
 	}
 
-	if f.dictFile != "" {
+	if f.indexMapping != nil {
+		//use mapping of template
+		indexMapping = f.indexMapping
+	}else if f.dictFile != "" {
 		//create index with chinese tokenizer support
 		indexMapping, err = f.CreateChineseMap(f.dictFile)
 		if err != nil {
@@ -87,11 +476,15 @@ func (f *Index) CreateIndex() error {
 		indexMapping = mapping.NewIndexMapping()
 	}
 
+	//add original doc and boost field mapping
+	f.addSourceMapping(indexMapping)
+	f.addBoostMapping(indexMapping)
+
 	//format sub dir path
 	subDir := fmt.Sprintf("%s/%s", f.indexDir, f.tag)
 
 	//init search index
-	index, subErr := bleve.New(subDir, indexMapping)
+	index, subErr := f.newIndex(subDir, indexMapping)
 	if subErr != nil {
 		//index had exists, open it.
 		if subErr == bleve.ErrorIndexPathExists {
@@ -131,6 +524,212 @@ func (f *Index) CreateChineseMap(dictPath string) (*mapping.IndexMappingImpl, er
 	// open a new index
 	indexMapping := bleve.NewIndexMapping()
 
+	//add chinese tokenizer and analyzer
+	err := f.addChineseAnalyzer(indexMapping, dictPath)
+	if err != nil {
+		return nil, err
+	}
+
+	//set default analyzer
+	indexMapping.DefaultAnalyzer = define.CustomTokenizerOfJieBa
+	return indexMapping, nil
+}
+
+//set tokenizer file
+func (f *Index) SetDictPath(dict string) bool {
+	if dict == "" {
+		return false
+	}
+	f.dictFile = dict
+	return true
+}
+
+///////////////
+//private func
+///////////////
+
+//reopen unloaded index
+func (f *Index) reopen() (bleve.Index, error) {
+	//reopen with locker
+	f.Lock()
+	if f.indexer != nil {
+		//opened by others
+		indexer := f.indexer
+		f.Unlock()
+		return indexer, nil
+	}
+	subDir := fmt.Sprintf("%s/%s", f.indexDir, f.tag)
+	indexer, err := bleve.Open(subDir)
+	if err != nil {
+		f.Unlock()
+		return nil, err
+	}
+	f.indexer = indexer
+	f.isUnloaded = false
+	f.Unlock()
+
+	//call cb for opened
+	if f.cbForOpen != nil {
+		f.cbForOpen(f.tag)
+	}
+	return indexer, nil
+}
+
+//load schema from index, should be called with locker
+//not marked as loaded if index not opened, reloaded on next call
+func (f *Index) loadSchema() {
+	if f.schemaLoaded {
+		return
+	}
+	f.schema = make(map[string]string)
+
+	//load saved schema
+	if f.indexer != nil {
+		schemaByte, err := f.indexer.GetInternal([]byte(define.InterKeyOfSchema))
+		if err == nil && schemaByte != nil {
+			schemaJson := json.NewIndexSchemaJson()
+			if schemaJson.Decode(schemaByte) == nil {
+				for k, v := range schemaJson.Fields {
+					f.schema[k] = v
+				}
+			}
+		}
+		f.schemaLoaded = true
+	}
+
+	//overwrite by declared schema
+	for k, v := range f.declared {
+		f.schema[k] = v
+	}
+}
+
+//get field type schema from document mapping
+func (f *Index) getMappingSchema(
+		dm *mapping.DocumentMapping,
+		path string,
+		result map[string]string,
+	) {
+	if dm == nil {
+		return
+	}
+	for _, fm := range dm.Fields {
+		switch fm.Type {
+		case "number":
+			result[path] = define.FieldTypeOfFloat
+		case "datetime":
+			result[path] = define.FieldTypeOfDateTime
+		case "geopoint":
+			result[path] = define.FieldTypeOfGeoPoint
+		case "boolean":
+			result[path] = define.FieldTypeOfBool
+		case "text":
+			result[path] = define.FieldTypeOfText
+		}
+	}
+	for k, v := range dm.Properties {
+		subPath := k
+		if path != "" {
+			subPath = path + "." + k
+		}
+		f.getMappingSchema(v, subPath, result)
+	}
+}
+
+//add store only field mapping for original doc json
+func (f *Index) addSourceMapping(indexMapping *mapping.IndexMappingImpl) {
+	sourceMapping := bleve.NewTextFieldMapping()
+	sourceMapping.Index = false
+	sourceMapping.Store = true
+	sourceMapping.IncludeInAll = false
+	sourceMapping.IncludeTermVectors = false
+	sourceMapping.DocValues = false
+	if indexMapping.DefaultMapping != nil {
+		indexMapping.DefaultMapping.AddFieldMappingsAt(define.DocFieldOfSource, sourceMapping)
+	}
+	for _, dm := range indexMapping.TypeMapping {
+		dm.AddFieldMappingsAt(define.DocFieldOfSource, sourceMapping)
+	}
+}
+
+//add doc boost field mapping
+//doc value used for query time boost
+func (f *Index) addBoostMapping(indexMapping *mapping.IndexMappingImpl) {
+	boostMapping := bleve.NewNumericFieldMapping()
+	boostMapping.Store = true
+	boostMapping.IncludeInAll = false
+	boostMapping.DocValues = true
+	if indexMapping.DefaultMapping != nil {
+		indexMapping.DefaultMapping.AddFieldMappingsAt(define.DocFieldOfBoost, boostMapping)
+	}
+	for _, dm := range indexMapping.TypeMapping {
+		dm.AddFieldMappingsAt(define.DocFieldOfBoost, boostMapping)
+	}
+}
+
+//add geo point field mapping of schema, if not mapped
+//dynamic mapping can't detect geo point field
+func (f *Index) addGeoPointMapping(
+		indexMapping *mapping.IndexMappingImpl,
+		schema map[string]string,
+	) {
+	if indexMapping.DefaultMapping == nil {
+		return
+	}
+	mapped := make(map[string]string)
+	f.getMappingSchema(indexMapping.DefaultMapping, "", mapped)
+	for path, kind := range schema {
+		if kind != define.FieldTypeOfGeoPoint || mapped[path] != "" {
+			continue
+		}
+		//find or create sub doc mapping of path
+		dm := indexMapping.DefaultMapping
+		keys := strings.Split(path, ".")
+		for _, k := range keys[:len(keys)-1] {
+			subDm, ok := dm.Properties[k]
+			if !ok {
+				subDm = mapping.NewDocumentMapping()
+				dm.AddSubDocumentMapping(k, subDm)
+			}
+			dm = subDm
+		}
+		dm.AddFieldMappingsAt(keys[len(keys)-1], mapping.NewGeoPointFieldMapping())
+	}
+}
+
+//create bleve index with setting
+func (f *Index) newIndex(
+		subDir string,
+		indexMapping *mapping.IndexMappingImpl,
+	) (bleve.Index, error) {
+	if f.setting == nil ||
+		(f.setting.IndexType == "" && f.setting.KVStore == "") {
+		return bleve.New(subDir, indexMapping)
+	}
+	indexType := f.setting.IndexType
+	if indexType == "" {
+		indexType = bleve.Config.DefaultIndexType
+	}
+	kvStore := f.setting.KVStore
+	if kvStore == "" {
+		kvStore = bleve.Config.DefaultKVStore
+	}
+	return bleve.NewUsing(subDir, indexMapping, indexType, kvStore, f.setting.KVConfig)
+}
+
+//add chinese tokenizer and analyzer into mapping
+func (f *Index) addChineseAnalyzer(
+		indexMapping *mapping.IndexMappingImpl,
+		dictPath string,
+	) error {
+	if dictPath == "" {
+		return errors.New("invalid dict path for index")
+	}
+
+	//check exists or not
+	if _, ok := indexMapping.CustomAnalysis.Analyzers[define.CustomTokenizerOfJieBa]; ok {
+		return nil
+	}
+
 	//set tokenizer
 	err := indexMapping.AddCustomTokenizer(
 		define.CustomTokenizerOfJieBa,
@@ -139,7 +738,7 @@ func (f *Index) CreateChineseMap(dictPath string) (*mapping.IndexMappingImpl, er
 			"type": define.CustomTokenizerOfJieBa,
 		})
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	// create a custom analyzer
@@ -154,21 +753,5 @@ func (f *Index) CreateChineseMap(dictPath string) (*mapping.IndexMappingImpl, er
 				"stop_en",
 			},
 		})
-
-	if err != nil {
-		return nil, err
-	}
-
-	//set default analyzer
-	indexMapping.DefaultAnalyzer = define.CustomTokenizerOfJieBa
-	return indexMapping, nil
-}
-
-//set tokenizer file
-func (f *Index) SetDictPath(dict string) bool {
-	if dict == "" {
-		return false
-	}
-	f.dictFile = dict
-	return true
+	return err
 }
\ No newline at end of file
@@ -0,0 +1,76 @@
+package face
+
+import (
+	"github.com/andyzhou/tinysearch/define"
+	"github.com/andyzhou/tinysearch/json"
+	"testing"
+	"time"
+)
+
+//test acquired index can't be unloaded
+func TestIndexUnloadAcquired(t *testing.T) {
+	m := newTestManager(t)
+	index := newTestIndex(t, m, "posts")
+	addTestDocs(t, m, index, map[string]map[string]interface{}{
+		"1": {"title": "apple", "uid": 1},
+	})
+
+	//skipped if acquired
+	indexer := index.AcquireIndex()
+	if m.unloadIndex(index) || !index.IsOpened() {
+		t.Fatal("acquired index should not be unloaded")
+	}
+	count, err := indexer.DocCount()
+	if err != nil || count != 1 {
+		t.Fatalf("acquired indexer should be usable, count:%v, err:%v", count, err)
+	}
+	index.ReleaseIndex()
+
+	//unloaded after released
+	if !m.unloadIndex(index) || index.IsOpened() {
+		t.Fatal("released index should be unloaded")
+	}
+	stats := m.GetIndexStats()
+	if stats.Evictions != 1 {
+		t.Fatalf("evictions %v, expect 1", stats.Evictions)
+	}
+
+	//reopened lazily, schema kept
+	opt := json.NewQueryOptJson()
+	opt.QueryKind = define.QueryKindOfMatchAll
+	ids := runTestQuery(t, m, index, opt)
+	if len(ids) != 1 || !index.IsOpened() {
+		t.Fatalf("hits %v of reopened index, expect 1", ids)
+	}
+	if index.GetSchema()["uid"] != define.FieldTypeOfInt {
+		t.Fatalf("schema %v of reopened index, expect uid", index.GetSchema())
+	}
+}
+
+//test idle index unloaded
+func TestIndexUnloadIdle(t *testing.T) {
+	m := newTestManager(t)
+	idle := newTestIndex(t, m, "idle")
+	active := newTestIndex(t, m, "active")
+	idle.GetIndex()
+	m.SetIdleTimeout(1)
+	time.Sleep(time.Millisecond * 1100)
+	active.GetIndex()
+
+	m.unloadIdleIndexes()
+	if idle.IsOpened() {
+		t.Fatal("idle index should be unloaded")
+	}
+	if !active.IsOpened() {
+		t.Fatal("active index should be kept")
+	}
+
+	//never unloaded if no idle timeout
+	idle.GetIndex()
+	m.SetIdleTimeout(0)
+	time.Sleep(time.Millisecond * 1100)
+	m.unloadIdleIndexes()
+	if !idle.IsOpened() {
+		t.Fatal("index should not be unloaded without idle timeout")
+	}
+}
@@ -2,8 +2,17 @@ package face
 
 import (
 	"errors"
+	"fmt"
+	"github.com/andyzhou/tinysearch/define"
 	"github.com/andyzhou/tinysearch/iface"
+	"github.com/andyzhou/tinysearch/json"
+	"log"
+	"path"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 /*
@@ -11,20 +20,34 @@ import (
  * @author <AndyZhou>
  * @mail <diudiu8848@163.com>
  * - sync doc for new or remove
+ * - auto create index by matched template
+ * - rolling index and pattern tag query
+ * - unload idle index, limit opened index count
  */
 
 //face info
 type Manager struct {
 	//inter data
-	dataPath string
-	dictFile string
-	indexes  *sync.Map //tag -> IIndex
+	dataPath    string
+	dictFile    string
+	indexes     *sync.Map                 //tag -> IIndex
+	templates   []*json.IndexTemplateJson //sorted by priority desc
+	indexLocker sync.Mutex                //used for index creation
+	idleTimeout int64                     //seconds, 0 means never unload, atomic
+	maxOpened   int64                     //open index budget, 0 means no limit, atomic
+	opens       int64
+	evictions   int64
 	//sub face
-	doc     iface.IDoc
-	query   iface.IQuery
-	agg     iface.IAgg
-	suggest iface.ISuggest
+	doc        iface.IDoc
+	query      iface.IQuery
+	agg        iface.IAgg
+	suggest    iface.ISuggest
+	percolator iface.IPercolator
+	rollover   *Rollover
+	closeChan  chan bool
+	quitOnce   sync.Once
 	Base
+	sync.RWMutex
 }
 
 //construct
@@ -43,18 +66,30 @@ func NewManager(
 		dataPath:dataPath,
 		dictFile: dictFilePath,
 		indexes:new(sync.Map),
+		templates: []*json.IndexTemplateJson{},
+		closeChan: make(chan bool, 1),
 		doc:NewDoc(),
 	}
 	//sub face init
 	this.suggest = NewSuggest(this)
 	this.query = NewQuery(this.suggest)
 	this.agg = NewAgg(this.query)
+	this.percolator = NewPercolator(this.query)
+	this.doc.SetPercolator(this.percolator)
+	this.rollover = NewRollover(this)
+
+	//spawn main process
+	go this.runMainProcess()
 	return this
 }
 
-//quit
+//quit, can be called more than once
 func (f *Manager) Quit() {
-	f.suggest.Quit()
+	f.quitOnce.Do(func() {
+		f.suggest.Quit()
+		f.percolator.Quit()
+		close(f.closeChan)
+	})
 }
 
 //get dict file
@@ -62,6 +97,49 @@ func (f *Manager) GetDictFile() string {
 	return f.dictFile
 }
 
+//set idle timeout seconds
+//idle index will be unloaded and reopened on next `GetIndex`
+func (f *Manager) SetIdleTimeout(seconds int) {
+	if seconds < 0 {
+		seconds = 0
+	}
+	atomic.StoreInt64(&f.idleTimeout, int64(seconds))
+}
+
+//set max opened index count
+func (f *Manager) SetMaxOpened(num int) {
+	if num < 0 {
+		num = 0
+	}
+	atomic.StoreInt64(&f.maxOpened, int64(num))
+}
+
+//get index stats
+func (f *Manager) GetIndexStats() *json.IndexStatsJson {
+	result := json.NewIndexStatsJson()
+	result.MaxOpened = int(atomic.LoadInt64(&f.maxOpened))
+	result.IdleTimeout = int(atomic.LoadInt64(&f.idleTimeout))
+	result.Opens = atomic.LoadInt64(&f.opens)
+	result.Evictions = atomic.LoadInt64(&f.evictions)
+	f.indexes.Range(func(k, v interface{}) bool {
+		index, ok := v.(iface.IIndex)
+		if !ok {
+			return true
+		}
+		result.Indexes++
+		if index.IsOpened() {
+			result.Opened++
+		}
+		return true
+	})
+	return result
+}
+
+//get index data path
+func (f *Manager) GetDataPath() string {
+	return f.dataPath
+}
+
 //set index data path
 func (f *Manager) SetDataPath(path string) {
 	f.dataPath = path
@@ -88,20 +166,316 @@ func (f *Manager) GetAgg() iface.IAgg {
 func (f *Manager) GetSuggest() iface.ISuggest {
 	return f.suggest
 }
+func (f *Manager) GetPercolator() iface.IPercolator {
+	return f.percolator
+}
+
+///////////////////////
+//api for index template
+///////////////////////
+
+//add or replace index template
+func (f *Manager) AddTemplate(tpl *json.IndexTemplateJson) error {
+	//basic check
+	if tpl == nil || tpl.Name == "" || tpl.Pattern == "" {
+		return errors.New("invalid parameter")
+	}
+	if _, err := path.Match(tpl.Pattern, ""); err != nil {
+		return fmt.Errorf("invalid pattern `%v`, err:%v", tpl.Pattern, err)
+	}
+
+	//try apply template, used for check mapping
+	testIndex := NewIndex(f.dataPath, tpl.Name, f.dictFile)
+	err := testIndex.ApplyTemplate(tpl)
+	if err != nil {
+		return err
+	}
+
+	//replace or append template
+	f.Lock()
+	defer f.Unlock()
+	isReplaced := false
+	for idx, v := range f.templates {
+		if v.Name == tpl.Name {
+			f.templates[idx] = tpl
+			isReplaced = true
+			break
+		}
+	}
+	if !isReplaced {
+		f.templates = append(f.templates, tpl)
+	}
+
+	//sort by priority desc
+	sort.SliceStable(f.templates, func(i, j int) bool {
+		return f.templates[i].Priority > f.templates[j].Priority
+	})
+	return nil
+}
+
+//remove index template
+func (f *Manager) RemoveTemplate(name string) error {
+	//basic check
+	if name == "" {
+		return errors.New("invalid parameter")
+	}
+	f.Lock()
+	defer f.Unlock()
+	for idx, v := range f.templates {
+		if v.Name == name {
+			f.templates = append(f.templates[:idx], f.templates[idx+1:]...)
+			return nil
+		}
+	}
+	return errors.New("no such template")
+}
+
+//get all index templates
+func (f *Manager) GetTemplates() []*json.IndexTemplateJson {
+	f.RLock()
+	defer f.RUnlock()
+	result := make([]*json.IndexTemplateJson, 0)
+	result = append(result, f.templates...)
+	return result
+}
+
+//get matched template by tag
+func (f *Manager) GetTemplate(tag string) *json.IndexTemplateJson {
+	//basic check
+	if tag == "" {
+		return nil
+	}
+	f.RLock()
+	defer f.RUnlock()
+	for _, v := range f.templates {
+		matched, _ := path.Match(v.Pattern, tag)
+		if matched {
+			return v
+		}
+	}
+	return nil
+}
+
+///////////////////////
+//api for rolling index
+///////////////////////
+
+//add or replace rollover policy
+//doc write on policy name will be routed to current rolling index
+func (f *Manager) AddRolloverPolicy(policy *json.RolloverPolicyJson) error {
+	return f.rollover.AddPolicy(policy)
+}
+
+//remove rollover policy
+func (f *Manager) RemoveRolloverPolicy(name string) error {
+	return f.rollover.RemovePolicy(name)
+}
+
+///////////////////
+//api for synonym
+///////////////////
+
+//set synonym set of index, replace old
+//take effect for next query, not need reindex
+func (f *Manager) SetSynonyms(tag string, synonyms *json.SynonymSetJson) error {
+	index := f.GetIndex(tag)
+	if index == nil {
+		return fmt.Errorf("can't get index by tag of %s", tag)
+	}
+	return index.SetSynonyms(synonyms)
+}
+
+//get synonym set of index
+//support pattern tag, synonyms of sub indexes merged
+func (f *Manager) GetSynonyms(tag string) (*json.SynonymSetJson, error) {
+	index := f.GetQueryIndex(tag)
+	if index == nil {
+		return nil, fmt.Errorf("can't get index by tag of %s", tag)
+	}
+	return index.GetSynonyms(), nil
+}
+
+////////////////////
+//api for percolate
+////////////////////
+
+//add or replace saved query of index
+//new doc of index will be checked by it
+func (f *Manager) AddPercolateQuery(
+		tag, id string,
+		opt *json.QueryOptJson,
+	) error {
+	index := f.GetIndex(tag)
+	if index == nil {
+		return fmt.Errorf("can't get index by tag of %s", tag)
+	}
+	return f.percolator.AddQuery(index, id, opt)
+}
+
+//remove saved query of index
+func (f *Manager) RemovePercolateQuery(tag, id string) error {
+	index := f.GetIndex(tag)
+	if index == nil {
+		return fmt.Errorf("can't get index by tag of %s", tag)
+	}
+	return f.percolator.RemoveQuery(index, id)
+}
+
+//get saved queries of index
+//support pattern tag, queries of sub indexes merged
+func (f *Manager) GetPercolateQueries(tag string) ([]*json.PercolateQueryJson, error) {
+	index := f.GetQueryIndex(tag)
+	if index == nil {
+		return nil, fmt.Errorf("can't get index by tag of %s", tag)
+	}
+	return f.percolator.GetQueries(index), nil
+}
 
 ////////////////
 //api for index
 ////////////////
 
-//remove index
+//get search index, if not exists and matched
+//any template, create it automatically.
+//used for doc write
+func (f *Manager) GetOrAddIndex(tag string) (iface.IIndex, error) {
+	//basic check
+	if tag == "" {
+		return nil, errors.New("invalid parameter")
+	}
+
+	//check rollover policy
+	if f.rollover.GetPolicy(tag) != nil {
+		writeTag, err := f.rollover.GetWriteTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		tag = writeTag
+	}
+
+	//check exists index
+	index := f.GetIndex(tag)
+	if index != nil {
+		return index, nil
+	}
+
+	//check template
+	if f.GetTemplate(tag) == nil {
+		return nil, fmt.Errorf("can't get index by tag of %s", tag)
+	}
+
+	//create new index by template
+	err := f.AddIndex(tag)
+	if err != nil {
+		return nil, err
+	}
+	index = f.GetIndex(tag)
+	if index == nil {
+		return nil, fmt.Errorf("can't get index by tag of %s", tag)
+	}
+	return index, nil
+}
+
+//remove index, data files will be kept
 func (f *Manager) RemoveIndex(tag string) error {
 	//basic check
 	if tag == "" || f.indexes == nil {
 		return errors.New("invalid tag or index is nil")
 	}
-	//remove index
-	f.indexes.Delete(tag)
-	return nil
+	//remove and close index
+	v, ok := f.indexes.LoadAndDelete(tag)
+	if !ok {
+		return nil
+	}
+	f.query.ClearCache(tag)
+	index, ok := v.(iface.IIndex)
+	if !ok {
+		return nil
+	}
+	return index.Close()
+}
+
+//drop index, include data files
+func (f *Manager) DropIndex(tag string) error {
+	//basic check
+	if tag == "" || f.indexes == nil {
+		return errors.New("invalid tag or index is nil")
+	}
+	//remove index and data files
+	v, ok := f.indexes.LoadAndDelete(tag)
+	if !ok {
+		return errors.New("no such index")
+	}
+	f.query.ClearCache(tag)
+	index, ok := v.(iface.IIndex)
+	if !ok {
+		return errors.New("invalid index type")
+	}
+	return index.RemoveIndex()
+}
+
+//get all opened index tags
+func (f *Manager) GetIndexTags() []string {
+	result := make([]string, 0)
+	f.indexes.Range(func(k, v interface{}) bool {
+		tag, ok := k.(string)
+		if ok {
+			result = append(result, tag)
+		}
+		return true
+	})
+	sort.Strings(result)
+	return result
+}
+
+//get index for query
+//support pattern tag like 'logs-*', or rollover policy name
+func (f *Manager) GetQueryIndex(tag string) iface.IIndex {
+	//check exists index
+	index := f.GetIndex(tag)
+	if index != nil {
+		return index
+	}
+
+	//check pattern tag
+	pattern := f.getPattern(tag)
+	if pattern == "" {
+		return nil
+	}
+	return f.GetAliasIndex(pattern)
+}
+
+//get all matched indexes
+//support pattern tag like 'logs-*', or rollover policy name
+//used for doc opt on every matched index, like remove
+func (f *Manager) GetMatchedIndexes(tag string) []iface.IIndex {
+	//check exists index
+	index := f.GetIndex(tag)
+	if index != nil {
+		return []iface.IIndex{index}
+	}
+
+	//check pattern tag
+	pattern := f.getPattern(tag)
+	if pattern == "" {
+		return []iface.IIndex{}
+	}
+	return f.getPatternIndexes(pattern)
+}
+
+//get alias index of all matched indexes
+func (f *Manager) GetAliasIndex(pattern string) iface.IIndex {
+	//basic check
+	if pattern == "" {
+		return nil
+	}
+
+	//get matched indexes
+	indexes := f.getPatternIndexes(pattern)
+	if len(indexes) <= 0 {
+		return nil
+	}
+	return NewAliasIndex(pattern, indexes...)
 }
 
 //get search index
@@ -134,7 +508,9 @@ func (f *Manager) AddIndex(tag string) error {
 		return errors.New("invalid parameter")
 	}
 
-	//check record
+	//check record with locker
+	f.indexLocker.Lock()
+	defer f.indexLocker.Unlock()
 	_, ok := f.indexes.Load(tag)
 	if ok {
 		return nil
@@ -142,12 +518,162 @@ func (f *Manager) AddIndex(tag string) error {
 
 	//init new index
 	index := NewIndex(f.dataPath, tag, f.dictFile)
+
+	//check and apply matched template
+	tpl := f.GetTemplate(tag)
+	if tpl != nil {
+		err = index.ApplyTemplate(tpl)
+		if err != nil {
+			return err
+		}
+	}
+
+	//create index
 	err = index.CreateIndex()
 	if err != nil {
 		return err
 	}
 
 	//sync into map
+	index.SetOpenCallback(f.cbForIndexOpened)
 	f.indexes.Store(tag, index)
+
+	//check open budget
+	f.cbForIndexOpened(tag)
 	return nil
-}
\ No newline at end of file
+}
+
+//////////////
+//private func
+//////////////
+
+//get pattern of tag, rollover policy name converted
+//return empty if not pattern
+func (f *Manager) getPattern(tag string) string {
+	pattern := tag
+	if f.rollover.GetPolicy(tag) != nil {
+		pattern = fmt.Sprintf("%s-*", tag)
+	}
+	if !strings.ContainsAny(pattern, "*?[") {
+		return ""
+	}
+	return pattern
+}
+
+//get opened indexes matched pattern
+func (f *Manager) getPatternIndexes(pattern string) []iface.IIndex {
+	indexes := make([]iface.IIndex, 0)
+	for _, tag := range f.GetIndexTags() {
+		matched, _ := path.Match(pattern, tag)
+		if !matched {
+			continue
+		}
+		index := f.GetIndex(tag)
+		if index == nil || index.GetIndex() == nil {
+			continue
+		}
+		indexes = append(indexes, index)
+	}
+	return indexes
+}
+
+//run main process
+func (f *Manager) runMainProcess() {
+	var (
+		ticker = time.NewTicker(time.Second * define.IndexCheckTicker)
+		m any = nil
+	)
+
+	//defer
+	defer func() {
+		if err := recover(); err != m {
+			log.Printf("tinysearch.Manager:mainProcess panic, err:%v", err)
+		}
+		ticker.Stop()
+	}()
+
+	//loop
+	for {
+		select {
+		case <- ticker.C://check indexes
+			{
+				f.rollover.CheckRetention()
+				f.rollover.CheckDocCount()
+				f.unloadIdleIndexes()
+			}
+		case <- f.closeChan:
+			return
+		}
+	}
+}
+
+//cb for index opened
+//unload least recently active indexes if out of budget
+func (f *Manager) cbForIndexOpened(tag string) {
+	atomic.AddInt64(&f.opens, 1)
+	maxOpened := int(atomic.LoadInt64(&f.maxOpened))
+	if maxOpened <= 0 {
+		return
+	}
+
+	//get opened indexes, exclude current
+	opened := make([]iface.IIndex, 0)
+	f.indexes.Range(func(k, v interface{}) bool {
+		index, ok := v.(iface.IIndex)
+		if ok && index.IsOpened() && index.GetTag() != tag {
+			opened = append(opened, index)
+		}
+		return true
+	})
+	overflow := len(opened) + 1 - maxOpened
+	if overflow <= 0 {
+		return
+	}
+
+	//sort by active time asc
+	sort.Slice(opened, func(i, j int) bool {
+		return opened[i].GetActiveTime().Before(opened[j].GetActiveTime())
+	})
+
+	//unload least recently active indexes
+	graceTime := time.Now().Add(-time.Second * define.IndexEvictGraceSeconds)
+	for _, index := range opened {
+		if overflow <= 0 || index.GetActiveTime().After(graceTime) {
+			break
+		}
+		if f.unloadIndex(index) {
+			overflow--
+		}
+	}
+}
+
+//unload idle indexes
+func (f *Manager) unloadIdleIndexes() {
+	idleTimeout := atomic.LoadInt64(&f.idleTimeout)
+	if idleTimeout <= 0 {
+		return
+	}
+	idleTime := time.Now().Add(-time.Second * time.Duration(idleTimeout))
+	f.indexes.Range(func(k, v interface{}) bool {
+		index, ok := v.(iface.IIndex)
+		if ok && index.IsOpened() && index.GetActiveTime().Before(idleTime) {
+			f.unloadIndex(index)
+		}
+		return true
+	})
+}
+
+//unload one index
+//acquired index skipped, return false
+func (f *Manager) unloadIndex(index iface.IIndex) bool {
+	err := index.Unload()
+	if err != nil {
+		log.Printf("tinysearch.Manager:unloadIndex %v failed, err:%v", index.GetTag(), err)
+		return false
+	}
+	if index.IsOpened() {
+		return false
+	}
+	atomic.AddInt64(&f.evictions, 1)
+	return true
+}
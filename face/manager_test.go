@@ -0,0 +1,104 @@
+package face
+
+import (
+	"github.com/andyzhou/tinysearch/define"
+	"github.com/andyzhou/tinysearch/json"
+	"testing"
+)
+
+//test index created by matched template on first write
+func TestTemplateAutoCreate(t *testing.T) {
+	m := newTestManager(t)
+	err := m.AddTemplate(&json.IndexTemplateJson{
+		Name: "tenant",
+		Pattern: "tenant-*",
+		Analyzer: "keyword",
+		Schema: map[string]string{"uid": define.FieldTypeOfInt},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//not matched any template
+	_, err = m.GetOrAddIndex("other")
+	if err == nil {
+		t.Fatal("index without template should not be created")
+	}
+
+	//created by template
+	index, err := m.GetOrAddIndex("tenant-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index.GetSchema()["uid"] != define.FieldTypeOfInt {
+		t.Fatalf("schema %v, expect declared uid", index.GetSchema())
+	}
+	addTestDocs(t, m, index, map[string]map[string]interface{}{
+		"1": {"uid": 1, "title": "Red Apple"},
+	})
+
+	//keyword analyzer, whole value as term
+	opt := json.NewQueryOptJson()
+	opt.QueryKind = define.QueryKindOfTerm
+	opt.TermPara.Field = "title"
+	opt.TermPara.Val = "Red Apple"
+	ids := runTestQuery(t, m, index, opt)
+	if len(ids) != 1 {
+		t.Fatalf("hits %v of keyword term, expect 1", ids)
+	}
+
+	//same index got again
+	again, err := m.GetOrAddIndex("tenant-1")
+	if err != nil || again != index {
+		t.Fatal("exists index should be returned")
+	}
+}
+
+//test template picked by priority
+func TestTemplatePriority(t *testing.T) {
+	m := newTestManager(t)
+	for _, tpl := range []*json.IndexTemplateJson{
+		{Name: "all", Pattern: "*", Priority: 1},
+		{Name: "logs", Pattern: "logs-*", Priority: 10},
+	} {
+		err := m.AddTemplate(tpl)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if tpl := m.GetTemplate("logs-1"); tpl == nil || tpl.Name != "logs" {
+		t.Fatal("template of higher priority should be picked")
+	}
+	if tpl := m.GetTemplate("posts"); tpl == nil || tpl.Name != "all" {
+		t.Fatal("template of wildcard pattern should be picked")
+	}
+
+	//replaced by same name
+	err := m.AddTemplate(&json.IndexTemplateJson{Name: "logs", Pattern: "logs-*", Priority: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tpl := m.GetTemplate("logs-1"); tpl == nil || tpl.Name != "all" {
+		t.Fatal("replaced template should be sorted by new priority")
+	}
+	if len(m.GetTemplates()) != 2 {
+		t.Fatalf("templates %v, expect 2", len(m.GetTemplates()))
+	}
+
+	//invalid template
+	err = m.AddTemplate(&json.IndexTemplateJson{Name: "bad", Pattern: "["})
+	if err == nil {
+		t.Fatal("invalid pattern should be rejected")
+	}
+	err = m.AddTemplate(&json.IndexTemplateJson{Name: "bad", Pattern: "bad-*", Mapping: []byte("{")})
+	if err == nil {
+		t.Fatal("invalid mapping should be rejected")
+	}
+}
+
+//test manager quit more than once
+func TestManagerQuitTwice(t *testing.T) {
+	m := NewManager(t.TempDir())
+	m.Quit()
+	m.Quit()
+}
@@ -0,0 +1,363 @@
+package face
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andyzhou/tinysearch/define"
+	"github.com/andyzhou/tinysearch/json"
+	"github.com/blevesearch/bleve/v2"
+	"math"
+	"sort"
+	"strings"
+)
+
+/*
+ * face for more like this
+ * @author <AndyZhou>
+ * @mail <diudiu8848@163.com>
+ * - top tf-idf terms of source doc or text, analyzed by field analyzer like jieba
+ * - converted into query tree, weighted should term queries
+ * - source doc excluded from result
+ */
+
+//inter selected term
+type mltTerm struct {
+	field string
+	term  string
+	score float64
+}
+
+//face info
+type MoreLikeThis struct {
+	Base
+}
+
+//construct
+func NewMoreLikeThis() *MoreLikeThis {
+	//self init
+	this := &MoreLikeThis{}
+	return this
+}
+
+//expand more like this query opt into query tree
+//leaf of query tree also expanded, return original opt if no need
+func (f *MoreLikeThis) ExpandOpt(
+		indexer bleve.Index,
+		opt *json.QueryOptJson,
+	) (*json.QueryOptJson, error) {
+	//basic check
+	if indexer == nil || opt == nil {
+		return nil, errors.New("invalid parameter")
+	}
+
+	//expand leaf nodes of query tree
+	if opt.QueryKind == define.QueryKindOfConjunctionQuery {
+		if opt.QueryTree == nil {
+			return opt, nil
+		}
+		node, err := f.expandNode(indexer, opt.QueryTree)
+		if err != nil {
+			return nil, err
+		}
+		newOpt := *opt
+		newOpt.QueryTree = node
+		return &newOpt, nil
+	}
+	if opt.QueryKind != define.QueryKindOfMoreLikeThis {
+		return opt, nil
+	}
+
+	//create weighted terms node
+	node, err := f.createNode(indexer, opt)
+	if err != nil {
+		return nil, err
+	}
+	newOpt := *opt
+	newOpt.QueryKind = define.QueryKindOfConjunctionQuery
+	newOpt.QueryTree = node
+	return &newOpt, nil
+}
+
+//////////////
+//private func
+//////////////
+
+//expand query tree node, return new node
+func (f *MoreLikeThis) expandNode(
+		indexer bleve.Index,
+		node *json.QueryNode,
+	) (*json.QueryNode, error) {
+	var (
+		err error
+	)
+	if node == nil {
+		return nil, nil
+	}
+	newNode := *node
+	if node.Query != nil {
+		newNode.Query, err = f.ExpandOpt(indexer, node.Query)
+		return &newNode, err
+	}
+	expandNodes := func(nodes []*json.QueryNode) []*json.QueryNode {
+		result := make([]*json.QueryNode, 0, len(nodes))
+		for _, v := range nodes {
+			subNode, subErr := f.expandNode(indexer, v)
+			if subErr != nil {
+				err = subErr
+			}
+			result = append(result, subNode)
+		}
+		return result
+	}
+	newNode.Must = expandNodes(node.Must)
+	newNode.Should = expandNodes(node.Should)
+	newNode.MustNot = expandNodes(node.MustNot)
+	newNode.Filter = expandNodes(node.Filter)
+	return &newNode, err
+}
+
+//create query node of top terms
+func (f *MoreLikeThis) createNode(
+		indexer bleve.Index,
+		opt *json.QueryOptJson,
+	) (*json.QueryNode, error) {
+	//get source texts of fields
+	para := opt.MoreLikeThis
+	if para == nil {
+		para = &json.MoreLikeThisPara{}
+	}
+	texts, err := f.getTexts(indexer, para, opt.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	//select top terms
+	terms, err := f.selectTerms(indexer, para, texts)
+	if err != nil {
+		return nil, err
+	}
+
+	//init node
+	node := json.NewQueryNode()
+	if len(terms) <= 0 {
+		//no any term, match none by empty doc ids
+		filter := json.NewFilterField()
+		filter.Kind = define.FilterKindSubDocIds
+		node.AddMust(json.NewFilterLeafNode(filter))
+		return node, nil
+	}
+
+	//weighted should term queries, boost normalized by top score
+	for _, v := range terms {
+		subOpt := json.NewQueryOptJson()
+		subOpt.QueryKind = define.QueryKindOfTerm
+		subOpt.TermPara.Field = v.field
+		subOpt.TermPara.Val = v.term
+		subNode := json.NewQueryLeafNode(subOpt)
+		subNode.Boost = v.score / terms[0].score
+		node.AddShould(subNode)
+	}
+	if para.MinShouldMatch > 0 {
+		node.MinShouldMatch = para.MinShouldMatch
+		if node.MinShouldMatch > len(terms) {
+			node.MinShouldMatch = len(terms)
+		}
+	}
+
+	//exclude source doc
+	if para.DocId != "" {
+		filter := json.NewFilterField()
+		filter.Kind = define.FilterKindSubDocIds
+		filter.DocIds = []string{para.DocId}
+		node.AddMustNot(json.NewFilterLeafNode(filter))
+	}
+	return node, nil
+}
+
+//select top tf-idf terms of texts
+//texts are field -> source texts
+func (f *MoreLikeThis) selectTerms(
+		indexer bleve.Index,
+		para *json.MoreLikeThisPara,
+		texts map[string][]string,
+	) ([]*mltTerm, error) {
+	//get readers of indexes
+	readers, err := f.getReaders(indexer)
+	defer func() {
+		for _, reader := range readers {
+			reader.Close()
+		}
+	}()
+	if err != nil {
+		return nil, err
+	}
+	docCount, err := indexer.DocCount()
+	if err != nil {
+		return nil, err
+	}
+
+	//init para
+	maxTerms := para.MaxTerms
+	if maxTerms <= 0 {
+		maxTerms = define.MoreLikeThisMaxTerms
+	}
+	minTermFreq := para.MinTermFreq
+	if minTermFreq <= 0 {
+		minTermFreq = 1
+	}
+	minDocFreq := para.MinDocFreq
+	if minDocFreq <= 0 {
+		minDocFreq = 1
+	}
+
+	//score terms of fields
+	terms := make([]*mltTerm, 0)
+	for field, values := range texts {
+		//count term freq
+		termFreqs := make(map[string]int)
+		for _, text := range values {
+			tokens, subErr := f.analyzeText(indexer, text, field)
+			if subErr != nil {
+				return nil, subErr
+			}
+			for _, token := range tokens {
+				term := string(token.Term)
+				if f.isWord(term) {
+					termFreqs[term]++
+				}
+			}
+		}
+
+		//tf * idf, idf same as lucene classic
+		for term, termFreq := range termFreqs {
+			if termFreq < minTermFreq {
+				continue
+			}
+			docFreq := f.getTermFreq(readers, []string{field}, term)
+			if docFreq < minDocFreq {
+				continue
+			}
+			idf := 1 + math.Log(float64(docCount) / float64(docFreq + 1))
+			if idf <= 0 {
+				continue
+			}
+			terms = append(terms, &mltTerm{
+				field: field,
+				term: term,
+				score: float64(termFreq) * idf,
+			})
+		}
+	}
+
+	//sort by score desc
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].score != terms[j].score {
+			return terms[i].score > terms[j].score
+		}
+		if terms[i].field != terms[j].field {
+			return terms[i].field < terms[j].field
+		}
+		return terms[i].term < terms[j].term
+	})
+	if len(terms) > maxTerms {
+		terms = terms[:maxTerms]
+	}
+	return terms, nil
+}
+
+//get source texts of fields from doc or text
+func (f *MoreLikeThis) getTexts(
+		indexer bleve.Index,
+		para *json.MoreLikeThisPara,
+		key string,
+	) (map[string][]string, error) {
+	texts := make(map[string][]string)
+
+	//source text
+	if para.DocId == "" {
+		text := para.Text
+		if text == "" {
+			text = key
+		}
+		if text == "" {
+			return nil, errors.New("more like this need doc id or text")
+		}
+		fields := para.Fields
+		if len(fields) <= 0 {
+			field, err := f.getDefaultField(indexer)
+			if err != nil {
+				return nil, err
+			}
+			fields = []string{field}
+		}
+		for _, field := range fields {
+			texts[field] = []string{text}
+		}
+		return texts, nil
+	}
+
+	//source doc
+	doc, err := indexer.Document(para.DocId)
+	if err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, fmt.Errorf("doc `%s` not found", para.DocId)
+	}
+	docMap := f.FormatDoc(doc)
+	if len(para.Fields) > 0 {
+		for _, field := range para.Fields {
+			f.collectTexts(field, f.getFieldValue(docMap, field), texts)
+		}
+	}else{
+		//all text fields
+		for field, value := range docMap {
+			if field == define.DocFieldOfBoost {
+				continue
+			}
+			f.collectTexts(field, value, texts)
+		}
+	}
+	return texts, nil
+}
+
+//get value of field path, like 'user.name'
+func (f *MoreLikeThis) getFieldValue(
+		docMap map[string]interface{},
+		field string,
+	) interface{} {
+	if value, ok := docMap[field]; ok {
+		return value
+	}
+	pos := strings.Index(field, ".")
+	if pos <= 0 {
+		return nil
+	}
+	subMap, ok := docMap[field[:pos]].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return f.getFieldValue(subMap, field[pos+1:])
+}
+
+//collect string values of field path
+func (f *MoreLikeThis) collectTexts(
+		field string,
+		value interface{},
+		texts map[string][]string,
+	) {
+	switch v := value.(type) {
+	case string:
+		if v != "" {
+			texts[field] = append(texts[field], v)
+		}
+	case []interface{}:
+		for _, subVal := range v {
+			f.collectTexts(field, subVal, texts)
+		}
+	case map[string]interface{}:
+		for subField, subVal := range v {
+			f.collectTexts(field + "." + subField, subVal, texts)
+		}
+	}
+}
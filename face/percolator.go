@@ -0,0 +1,380 @@
+package face
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andyzhou/tinysearch/define"
+	"github.com/andyzhou/tinysearch/iface"
+	"github.com/andyzhou/tinysearch/json"
+	"github.com/andyzhou/tinysearch/lib"
+	"github.com/blevesearch/bleve/v2"
+	"log"
+	"path"
+	"sort"
+	"sync"
+	"time"
+)
+
+/*
+ * face for percolator
+ * @author <AndyZhou>
+ * @mail <diudiu8848@163.com>
+ * - saved queries registered per index, kept in index internal storage
+ * - new doc sent to queue, percolated by background worker
+ * - new doc indexed into single doc memory index, then run saved queries on it
+ * - built search requests cached per index, rebuilt if saved queries or synonyms changed
+ * - matches sent to callback and subscribed streams
+ */
+
+//inter data
+type (
+	//subscriber
+	percolateSub struct {
+		pattern string //index tag pattern, empty means all
+		ch      chan *json.PercolateMatchJson
+	}
+
+	//new doc of queue
+	percolateReq struct {
+		index iface.IIndex
+		docId string
+		doc   map[string]interface{}
+	}
+
+	//built search requests of index
+	percolateCache struct {
+		percolates *json.PercolateSetJson          //built from, replaced by add or remove
+		synonyms   *json.SynonymSetJson            //built from, replaced by synonyms setup
+		requests   map[string]*bleve.SearchRequest //query id -> search request
+	}
+)
+
+//face info
+type Percolator struct {
+	query   iface.IQuery //refer of parent, used for build search request
+	synonym *Synonym
+	queue   *lib.Queue
+	cb      func(match *json.PercolateMatchJson)
+	subs    map[int64]*percolateSub
+	subId   int64
+	caches  map[string]*percolateCache //index tag -> built requests
+	locker  sync.Mutex                 //used for saved query change and caches
+	sync.RWMutex
+}
+
+//construct
+func NewPercolator(query iface.IQuery) *Percolator {
+	//self init
+	this := &Percolator{
+		query: query,
+		synonym: NewSynonym(),
+		queue: lib.NewQueue(),
+		subs: make(map[int64]*percolateSub),
+		caches: make(map[string]*percolateCache),
+	}
+	this.interInit()
+	return this
+}
+
+//quit
+func (f *Percolator) Quit() {
+	if f.queue != nil {
+		f.queue.Quit()
+	}
+}
+
+//add or replace saved query of index
+//query checked before saved, more like this not support
+func (f *Percolator) AddQuery(
+		index iface.IIndex,
+		id string,
+		opt *json.QueryOptJson,
+	) error {
+	//basic check
+	if index == nil || id == "" || opt == nil {
+		return errors.New("invalid parameter")
+	}
+
+	//check query
+	_, err := f.query.BuildSearchReq(f.synonym.ExpandOpt(index, opt))
+	if err != nil {
+		return err
+	}
+
+	//replace set, running percolate not affected
+	f.locker.Lock()
+	defer f.locker.Unlock()
+	old := index.GetPercolates()
+	if old.GetQuery(id) == nil && len(old.Queries) >= define.PercolateQueriesMax {
+		return fmt.Errorf("saved queries of index reached max %v", define.PercolateQueriesMax)
+	}
+	percolates := json.NewPercolateSetJson()
+	percolates.Queries = append(percolates.Queries, old.Queries...)
+	percolates.AddQuery(&json.PercolateQueryJson{
+		Id: id,
+		Query: opt,
+		CreateAt: time.Now().Unix(),
+	})
+	delete(f.caches, index.GetTag())
+	return index.SetPercolates(percolates)
+}
+
+//remove saved query of index
+func (f *Percolator) RemoveQuery(
+		index iface.IIndex,
+		id string,
+	) error {
+	//basic check
+	if index == nil || id == "" {
+		return errors.New("invalid parameter")
+	}
+
+	//replace set, running percolate not affected
+	f.locker.Lock()
+	defer f.locker.Unlock()
+	old := index.GetPercolates()
+	if old.GetQuery(id) == nil {
+		return errors.New("no such saved query")
+	}
+	percolates := json.NewPercolateSetJson()
+	percolates.Queries = append(percolates.Queries, old.Queries...)
+	percolates.RemoveQuery(id)
+	delete(f.caches, index.GetTag())
+	return index.SetPercolates(percolates)
+}
+
+//get saved queries of index
+func (f *Percolator) GetQueries(
+		index iface.IIndex,
+	) []*json.PercolateQueryJson {
+	result := make([]*json.PercolateQueryJson, 0)
+	if index == nil {
+		return result
+	}
+	result = append(result, index.GetPercolates().Queries...)
+	return result
+}
+
+//set callback for matches
+//called in percolate worker, should not be blocked
+func (f *Percolator) SetCallback(cb func(match *json.PercolateMatchJson)) {
+	f.Lock()
+	defer f.Unlock()
+	f.cb = cb
+}
+
+//subscribe matches of indexes
+//pattern like 'posts-*', empty means all indexes
+//matches dropped if chan is full
+func (f *Percolator) Subscribe(
+		pattern string,
+	) (int64, <-chan *json.PercolateMatchJson) {
+	f.Lock()
+	defer f.Unlock()
+	f.subId++
+	sub := &percolateSub{
+		pattern: pattern,
+		ch: make(chan *json.PercolateMatchJson, define.PercolateChanSize),
+	}
+	f.subs[f.subId] = sub
+	return f.subId, sub.ch
+}
+
+//unsubscribe matches, chan will be closed
+func (f *Percolator) Unsubscribe(id int64) {
+	f.Lock()
+	defer f.Unlock()
+	sub, ok := f.subs[id]
+	if !ok {
+		return
+	}
+	delete(f.subs, id)
+	close(sub.ch)
+}
+
+//send new doc to percolate queue, matched in background
+//doc is formatted kv map of adding, skipped if no saved queries
+func (f *Percolator) Percolate(
+		index iface.IIndex,
+		docId string,
+		doc map[string]interface{},
+	) error {
+	//basic check
+	if index == nil || docId == "" || doc == nil {
+		return errors.New("invalid parameter")
+	}
+	if len(index.GetPercolates().Queries) <= 0 {
+		return nil
+	}
+
+	//send to queue
+	req := percolateReq{
+		index: index,
+		docId: docId,
+		doc: doc,
+	}
+	_, err := f.queue.SendData(req)
+	return err
+}
+
+//////////////
+//private func
+//////////////
+
+//cb for queue opt
+func (f *Percolator) cbForQueueOpt(
+		input interface{},
+	) (interface{}, error) {
+	var (
+		m any = nil
+	)
+	defer func() {
+		if err := recover(); err != m {
+			log.Printf("Percolator:cbForQueueOpt panic, err:%v\n", err)
+		}
+	}()
+
+	//check
+	req, ok := input.(percolateReq)
+	if !ok {
+		return nil, errors.New("invalid request data format")
+	}
+
+	//process percolate
+	match, err := f.percolateProcess(&req)
+	if err != nil {
+		log.Printf("Percolator:cbForQueueOpt percolate doc %v failed, err:%v\n", req.docId, err)
+	}
+	return match, err
+}
+
+//percolate new doc with saved queries of index
+func (f *Percolator) percolateProcess(
+		req *percolateReq,
+	) (*json.PercolateMatchJson, error) {
+	//get built search requests
+	requests := f.getRequests(req.index)
+	if len(requests) <= 0 {
+		return nil, nil
+	}
+
+	//get indexer
+	indexer := req.index.AcquireIndex()
+	defer req.index.ReleaseIndex()
+	if indexer == nil {
+		return nil, errors.New("can't get indexer")
+	}
+
+	//index doc into memory, analyzed by same mapping
+	memIndex, err := bleve.NewMemOnly(indexer.Mapping())
+	if err != nil {
+		return nil, err
+	}
+	defer memIndex.Close()
+	err = memIndex.Index(req.docId, req.doc)
+	if err != nil {
+		return nil, err
+	}
+
+	//run saved queries one by one
+	match := json.NewPercolateMatchJson()
+	match.Tag = req.index.GetTag()
+	match.DocId = req.docId
+	for id, searchRequest := range requests {
+		searchResult, subErr := memIndex.Search(searchRequest)
+		if subErr != nil {
+			log.Printf("Percolator:percolateProcess query %v failed, err:%v\n", id, subErr)
+			continue
+		}
+		if searchResult.Total > 0 {
+			match.QueryIds = append(match.QueryIds, id)
+		}
+	}
+
+	//send matches
+	if len(match.QueryIds) > 0 {
+		sort.Strings(match.QueryIds)
+		f.notify(match)
+	}
+	return match, nil
+}
+
+//get built search requests of index saved queries
+//rebuilt if saved queries or synonyms replaced
+func (f *Percolator) getRequests(
+		index iface.IIndex,
+	) map[string]*bleve.SearchRequest {
+	percolates := index.GetPercolates()
+	synonyms := index.GetSynonyms()
+
+	//get cached requests
+	f.locker.Lock()
+	defer f.locker.Unlock()
+	cache, ok := f.caches[index.GetTag()]
+	if ok && cache.percolates == percolates && cache.synonyms == synonyms {
+		return cache.requests
+	}
+
+	//build requests of saved queries
+	cache = &percolateCache{
+		percolates: percolates,
+		synonyms: synonyms,
+		requests: make(map[string]*bleve.SearchRequest),
+	}
+	for _, v := range percolates.Queries {
+		searchRequest, err := f.buildRequest(index, v.Query)
+		if err != nil {
+			log.Printf("Percolator:getRequests build query %v failed, err:%v\n", v.Id, err)
+			continue
+		}
+		cache.requests[v.Id] = searchRequest
+	}
+	f.caches[index.GetTag()] = cache
+	return cache.requests
+}
+
+//build search request of saved query, only count needed
+func (f *Percolator) buildRequest(
+		index iface.IIndex,
+		opt *json.QueryOptJson,
+	) (*bleve.SearchRequest, error) {
+	if opt == nil {
+		return nil, errors.New("invalid saved query")
+	}
+	searchRequest, err := f.query.BuildSearchReq(f.synonym.ExpandOpt(index, opt))
+	if err != nil {
+		return nil, err
+	}
+	searchRequest.Size = 0
+	return searchRequest, nil
+}
+
+//send match to callback and subscribers
+func (f *Percolator) notify(match *json.PercolateMatchJson) {
+	f.RLock()
+	cb := f.cb
+	for _, sub := range f.subs {
+		if sub.pattern != "" {
+			matched, _ := path.Match(sub.pattern, match.Tag)
+			if !matched {
+				continue
+			}
+		}
+		select {
+		case sub.ch <- match:
+		default:
+			log.Printf("Percolator:notify chan is full, match of doc %v dropped\n", match.DocId)
+		}
+	}
+	f.RUnlock()
+
+	//run callback
+	if cb != nil {
+		cb(match)
+	}
+}
+
+//inter init
+func (f *Percolator) interInit() {
+	f.queue.SetCallback(f.cbForQueueOpt)
+}
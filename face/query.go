@@ -1,15 +1,21 @@
 package face
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/andyzhou/tinysearch/define"
 	"github.com/andyzhou/tinysearch/iface"
 	"github.com/andyzhou/tinysearch/json"
 	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/geo"
 	"github.com/blevesearch/bleve/v2/search"
 	"github.com/blevesearch/bleve/v2/search/query"
-	index "github.com/blevesearch/bleve_index_api"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
 )
 
 /*
@@ -21,6 +27,13 @@ import (
 //face info
 type Query struct {
 	suggester iface.ISuggest //refer of parent
+	scorer *Score
+	highlighter *HighLight
+	synonym *Synonym
+	speller *Spell
+	mlt *MoreLikeThis
+	collapser *Collapse
+	cache *QueryCache
 	Base
 }
 
@@ -30,9 +43,41 @@ func NewQuery(suggester iface.ISuggest) *Query {
 	this := &Query{
 		suggester:suggester,
 	}
+	this.scorer = NewScore(this)
+	this.highlighter = NewHighLight()
+	this.synonym = NewSynonym()
+	this.speller = NewSpell()
+	this.mlt = NewMoreLikeThis()
+	this.collapser = NewCollapse()
+	this.cache = NewQueryCache()
 	return this
 }
 
+//set query cache limit, old caches cleared
+//max size is results of one index, <= 0 means disabled
+func (f *Query) SetCache(maxSize int, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = time.Duration(define.QueryCacheTTLDefault) * time.Second
+	}
+	f.cache.SetLimit(maxSize, ttl)
+}
+
+//clear query cache of indexes, all cleared if no tags
+func (f *Query) ClearCache(tags ...string) {
+	f.cache.Clear(tags...)
+}
+
+//get query cache stats of index
+func (f *Query) GetCacheStats(tag string) *json.QueryCacheStatsJson {
+	return f.cache.GetStats(tag)
+}
+
+//set pinyin hook for spell suggestion
+//used for pinyin similar candidates of chinese word
+func (f *Query) SetHookForPinyin(hook func(word string) string) {
+	f.speller.SetHookForPinyin(hook)
+}
+
 //query all doc
 func (f *Query) QueryAll(
 		index iface.IIndex,
@@ -44,15 +89,23 @@ func (f *Query) QueryAll(
 	}
 
 	//get indexer
-	indexer := index.GetIndex()
+	indexer := index.AcquireIndex()
+	defer index.ReleaseIndex()
 	if indexer == nil {
 		return nil, errors.New("can't get indexer")
 	}
 
+	//init query opt
+	opt := json.NewQueryOptJson()
+	if needDocs != nil && len(needDocs) > 0 {
+		opt.NeedDocs = needDocs[0]
+	}
+
 	//init search request
+	beginAt := time.Now()
 	matchAll := bleve.NewMatchAllQuery()
 	searchRequest := bleve.NewSearchRequest(matchAll)
-	searchRequest.Explain = true
+	f.setDocFields(indexer, searchRequest, opt)
 
 	//begin search
 	searchResult, err := indexer.Search(searchRequest)
@@ -65,6 +118,7 @@ func (f *Query) QueryAll(
 		result := &json.SearchResultJson{
 			Total: 0,
 			Records: nil,
+			Took: f.getTook(beginAt),
 		}
 		return result, nil
 	}
@@ -72,9 +126,11 @@ func (f *Query) QueryAll(
 	//init result
 	result := json.NewSearchResultJson()
 	result.Total = searchResult.Total
+	result.MaxScore = searchResult.MaxScore
 
 	//format records
-	result.Records = f.formatResult(indexer, &searchResult.Hits, needDocs...)
+	result.Records = f.formatResult(index, &searchResult.Hits, opt)
+	result.Took = f.getTook(beginAt)
 	return result, nil
 }
 
@@ -83,40 +139,30 @@ func (f *Query) Query(
 		index iface.IIndex,
 		opt *json.QueryOptJson,
 	) (*json.SearchResultJson, error) {
+	return f.QueryWithContext(context.Background(), index, opt)
+}
+
+//query doc with context
+//stopped if context done, partial result returned if timeout of opt reached
+func (f *Query) QueryWithContext(
+		ctx context.Context,
+		index iface.IIndex,
+		opt *json.QueryOptJson,
+	) (*json.SearchResultJson, error) {
 	//basic check
-	if index == nil || opt == nil {
+	if ctx == nil || index == nil || opt == nil {
 		return nil, errors.New("invalid parameter")
 	}
 
 	//get indexer
-	indexer := index.GetIndex()
+	indexer := index.AcquireIndex()
+	defer index.ReleaseIndex()
 	if indexer == nil {
 		return nil, errors.New("can't get indexer")
 	}
 
-	//build search request
-	searchRequest := f.BuildSearchReq(opt)
-
-	//set high light
-	if opt.HighLight {
-		//other search request
-		searchRequest.Highlight = bleve.NewHighlight()
-	}
-
-	//sort by
-	if opt.Sort != nil {
-		customSort := make([]search.SearchSort, 0)
-		for _, sort := range opt.Sort {
-			cs := search.SortField{
-				Field: sort.Field,
-				Desc: sort.Desc,
-			}
-			customSort = append(customSort, &cs)
-		}
-		searchRequest.SortByCustom(customSort)
-	}
-
 	//check offset
+	beginAt := time.Now()
 	if opt.Size > 0 {
 		if opt.Offset < 0 {
 			opt.Offset = 0
@@ -133,22 +179,172 @@ func (f *Query) Query(
 		opt.Size = opt.PageSize
 	}
 
+	//check query cache
+	//version got before search, result of old version never be hit
+	var (
+		cacheKey string
+	)
+	version := index.GetVersion()
+	useCache := !opt.NoCache && f.cache.IsEnabled()
+	if useCache {
+		cacheKey = f.cache.GetKey(opt)
+		if result := f.cache.Get(index.GetTag(), cacheKey, version); result != nil {
+			result.Took = f.getTook(beginAt)
+			result.Timings = map[string]float64{
+				define.QueryPhaseOfCache: result.Took,
+			}
+			return result, nil
+		}
+	}
+
+	//build search request
+	//more like this converted into terms, key expanded by synonyms
+	timings := make(map[string]float64)
+	queryOpt, err := f.mlt.ExpandOpt(indexer, opt)
+	if err != nil {
+		return nil, err
+	}
+	searchRequest, err := f.BuildSearchReq(f.synonym.ExpandOpt(index, queryOpt))
+	if err != nil {
+		return nil, err
+	}
+
+	//set high light, fragments created on page hits
+	highLightOpt := f.highlighter.GetOpt(opt)
+	if highLightOpt != nil {
+		searchRequest.IncludeLocations = true
+	}
+
+	//sort by
+	customSort, err := f.createSort(opt.Sort)
+	if err != nil {
+		return nil, err
+	}
+	searchRequest.SortByCustom(customSort)
+
 	//set others
 	searchRequest.From = opt.Offset
 	searchRequest.Size = opt.Size
-	searchRequest.Explain = true
+	searchRequest.Explain = opt.Explain
 
-	//begin search
-	searchResult, err := indexer.Search(searchRequest)
+	//set stored fields for docs
+	f.setDocFields(indexer, searchRequest, opt)
+
+	//check cursor paging
+	useCursor := len(opt.SearchAfter) > 0 || len(opt.SearchBefore) > 0
+	collapseOpt := f.collapser.GetOpt(opt)
+	if useCursor && collapseOpt != nil {
+		return nil, errors.New("collapse not support cursor paging")
+	}
+	if useCursor {
+		searchRequest.From = 0
+		if len(opt.SearchAfter) > 0 {
+			searchRequest.SetSearchAfter(opt.SearchAfter)
+		}else{
+			searchRequest.SetSearchBefore(opt.SearchBefore)
+		}
+	}
+
+	//check rescore by score functions, fetch top hits window
+	//cursor paging use original score
+	needRescore := !useCursor && f.needRescore(opt)
+	if needRescore {
+		window := define.QueryRescoreWindow
+		if opt.Scoring != nil && opt.Scoring.Window > 0 {
+			window = opt.Scoring.Window
+		}
+		searchRequest.From = 0
+		searchRequest.Size = opt.Offset + opt.Size
+		if searchRequest.Size < window {
+			searchRequest.Size = window
+		}
+		searchRequest.Fields = append(searchRequest.Fields, define.DocFieldOfBoost)
+		searchRequest.Fields = append(searchRequest.Fields, f.scorer.GetFields(opt.Scoring)...)
+	}else if f.needDocBoost(indexer, opt) {
+		//doc boost applied in query time
+		searchRequest.Query = newDocBoostQuery(searchRequest.Query)
+	}
+
+	//check collapse, fetch top hits window for grouping
+	if collapseOpt != nil {
+		f.collapser.Prepare(searchRequest, collapseOpt, opt.Offset, opt.Size)
+	}
+
+	//begin search, stopped if context done or timeout
+	timings[define.QueryPhaseOfBuild] = f.getTook(beginAt)
+	phaseAt := time.Now()
+	timeLimit := f.setTimeLimit(searchRequest, opt.Timeout)
+	searchResult, err := indexer.SearchInContext(ctx, searchRequest)
 	if err != nil {
 		return nil, err
 	}
+	if ctx.Err() != nil {
+		//caller gone, like rpc deadline reached
+		return nil, ctx.Err()
+	}
+	timings[define.QueryPhaseOfSearch] = f.getTook(phaseAt)
+	timedOut, searchErrors := f.getSearchErrors(index.GetTag(), searchResult.Status, timeLimit)
+	maxScore := searchResult.MaxScore
+
+	//rescore hits, all hits kept for collapse
+	if needRescore {
+		phaseAt = time.Now()
+		rescoreOpt := opt
+		if collapseOpt != nil {
+			windowOpt := *opt
+			windowOpt.Offset = 0
+			windowOpt.Size = len(searchResult.Hits)
+			rescoreOpt = &windowOpt
+		}
+		searchResult.Hits, maxScore, err = f.rescoreHits(indexer, index.GetSchema(), rescoreOpt, searchResult.Hits)
+		if err != nil {
+			return nil, err
+		}
+		timings[define.QueryPhaseOfRescore] = f.getTook(phaseAt)
+	}
+
+	//collapse hits, best hit of groups picked up
+	var (
+		groups []*collapseGroup
+		groupTotal uint64
+	)
+	if collapseOpt != nil {
+		groups, groupTotal = f.collapser.Apply(collapseOpt, searchResult, index.GetSchema(), opt.Offset, opt.Size)
+		searchResult.Hits = make(search.DocumentMatchCollection, 0, len(groups))
+		for _, group := range groups {
+			searchResult.Hits = append(searchResult.Hits, group.hits[0])
+		}
+	}
 
 	//check result
 	if searchResult.Total <= 0 {
 		result := &json.SearchResultJson{
 			Total: 0,
 			Records: nil,
+			Timings: timings,
+			TimedOut: timedOut,
+			Partial: len(searchErrors) > 0,
+			Errors: searchErrors,
+		}
+
+		//suggest corrected keys
+		spellOpt := f.speller.GetOpt(opt)
+		if spellOpt != nil && opt.Key != "" && !timedOut {
+			phaseAt = time.Now()
+			fields := make([]string, 0, len(opt.Fields))
+			for _, field := range opt.Fields {
+				fieldName, _ := f.parseFieldBoost(field)
+				fields = append(fields, fieldName)
+			}
+			result.Spells, err = f.speller.Suggest(indexer, opt.Key, fields, spellOpt)
+			if err != nil {
+				return nil, err
+			}
+			timings[define.QueryPhaseOfSpell] = f.getTook(phaseAt)
+		}
+		result.Took = f.getTook(beginAt)
+		if useCache && !result.Partial {
+			f.cache.Set(index.GetTag(), cacheKey, version, result)
 		}
 		return result, nil
 	}
@@ -158,7 +354,7 @@ func (f *Query) Query(
 	if f.suggester != nil &&
 		opt.Key != "" &&
 		(opt.Filters == nil || len(opt.Filters) <= 0) &&
-		opt.Page == 1 {
+		opt.Page == 1 && !useCursor {
 		if searchResult.Total > 0 && opt.SuggestTag != "" {
 			suggestJson := json.NewSuggestJson()
 			suggestJson.Key = opt.Key
@@ -170,18 +366,139 @@ func (f *Query) Query(
 	//init result
 	result := json.NewSearchResultJson()
 	result.Total = searchResult.Total
+	result.Groups = groupTotal
+	result.MaxScore = maxScore
+	result.Timings = timings
+	result.TimedOut = timedOut
+	result.Partial = len(searchErrors) > 0
+	result.Errors = searchErrors
 
 	//format records
-	result.Records = f.formatResult(indexer, &searchResult.Hits, opt.NeedDocs)
+	phaseAt = time.Now()
+	if highLightOpt != nil {
+		err = f.highlighter.Apply(indexer, highLightOpt, searchResult.Hits)
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range groups {
+			if len(group.hits) > 1 {
+				err = f.highlighter.Apply(indexer, highLightOpt, group.hits[1:])
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	result.Records = f.formatResult(index, &searchResult.Hits, opt)
+	if collapseOpt != nil {
+		f.formatGroups(index, result.Records, groups, opt)
+	}
+	result.Timings[define.QueryPhaseOfFormat] = f.getTook(phaseAt)
+
+	//set cursor by sort key of first and last hit
+	//rescored or collapsed hits can't be used for cursor paging
+	if !needRescore && collapseOpt == nil && len(searchResult.Hits) > 0 {
+		hits := searchResult.Hits
+		result.PrevCursor = f.getCursor(searchRequest.Sort, hits[0])
+		result.Cursor = f.getCursor(searchRequest.Sort, hits[len(hits)-1])
+	}
+	result.Took = f.getTook(beginAt)
+	if useCache && !result.Partial {
+		f.cache.Set(index.GetTag(), cacheKey, version, result)
+	}
 	return result, nil
 }
 
+//delete docs matched query opt
+//return deleted doc count
+func (f *Query) DeleteByQuery(
+		index iface.IIndex,
+		opt *json.QueryOptJson,
+	) (int64, error) {
+	var (
+		deleted int64
+	)
+	//basic check
+	if index == nil || opt == nil {
+		return deleted, errors.New("invalid parameter")
+	}
+	if _, ok := index.(*AliasIndex); ok {
+		//alias batch not support, delete on matched indexes one by one
+		return deleted, errors.New("alias index can't delete docs")
+	}
+
+	//build search request, key expanded by synonyms
+	searchRequest, err := f.BuildSearchReq(f.synonym.ExpandOpt(index, opt))
+	if err != nil {
+		return deleted, err
+	}
+
+	//avoid remove all docs by mistake
+	//match all only allowed by query kind
+	_, isMatchAll := searchRequest.Query.(*query.MatchAllQuery)
+	if searchRequest.Query == nil ||
+		(isMatchAll && opt.QueryKind != define.QueryKindOfMatchAll) {
+		return deleted, errors.New("no any query condition")
+	}
+
+	//get indexer
+	indexer := index.AcquireIndex()
+	defer index.ReleaseIndex()
+	if indexer == nil {
+		return deleted, errors.New("can't get indexer")
+	}
+	searchRequest.From = 0
+	searchRequest.Size = define.DeleteByQueryBatchSize
+
+	//search and remove batch docs one by one
+	for {
+		searchResult, subErr := indexer.Search(searchRequest)
+		if subErr != nil {
+			return deleted, subErr
+		}
+		if len(searchResult.Hits) <= 0 {
+			break
+		}
+		batch := indexer.NewBatch()
+		for _, hit := range searchResult.Hits {
+			batch.Delete(hit.ID)
+		}
+		subErr = indexer.Batch(batch)
+		if subErr != nil {
+			return deleted, subErr
+		}
+		deleted += int64(len(searchResult.Hits))
+		index.IncVersion()
+		if len(searchResult.Hits) < searchRequest.Size {
+			break
+		}
+	}
+	return deleted, nil
+}
+
 //build query object
 func (f *Query) BuildSearchReq(
-	opt *json.QueryOptJson) *bleve.SearchRequest {
+	opt *json.QueryOptJson) (*bleve.SearchRequest, error) {
+	//build doc query with filters
+	docQuery, err := f.buildQuery(opt)
+	if err != nil {
+		return nil, err
+	}
+	//init search request
+	searchRequest := bleve.NewSearchRequest(docQuery)
+	return searchRequest, nil
+}
+
+////////////////////////////
+//create query tree
+////////////////////////////
+
+//build doc query with filters of opt
+func (f *Query) buildQuery(
+	opt *json.QueryOptJson) (query.Query, error) {
 	var (
 		docQuery query.Query
-		searchRequest *bleve.SearchRequest
+		err error
 	)
 
 	//setup search kind
@@ -200,6 +517,25 @@ func (f *Query) BuildSearchReq(
 		docQuery = f.createGeoDistanceQuery(opt)
 	case define.QueryKindOfMatchAll:
 		docQuery = bleve.NewMatchAllQuery()
+	case define.QueryKindOfConjunctionQuery:
+		docQuery, err = f.createQueryTree(opt.QueryTree)
+		if err != nil {
+			return nil, err
+		}
+	case define.QueryKindOfQueryString:
+		docQuery, err = f.createQueryStringQuery(opt)
+		if err != nil {
+			return nil, err
+		}
+	case define.QueryKindOfFuzzy:
+		docQuery = f.createFuzzyQuery(opt)
+	case define.QueryKindOfWildcard:
+		docQuery = f.createWildcardQuery(opt)
+	case define.QueryKindOfRegexp:
+		docQuery = f.createRegexpQuery(opt)
+	case define.QueryKindOfMoreLikeThis:
+		//need terms of index, expanded before build
+		return nil, errors.New("more like this query should be expanded by index")
 	default:
 		if opt.Key != "" {
 			docQuery = f.createMatchQuery(opt)
@@ -210,19 +546,102 @@ func (f *Query) BuildSearchReq(
 
 	//set filter fields
 	//create bool query
-	boolQuery := f.createFilterQuery(opt)
-	if boolQuery != nil {
-		if docQuery != nil {
-			//add must doc query
-			boolQuery.AddMust(docQuery)
-		}
-		//init multi condition search request
-		searchRequest = bleve.NewSearchRequest(boolQuery)
+	boolQuery, err := f.createFilterQuery(opt)
+	if err != nil {
+		return nil, err
+	}
+	if boolQuery == nil {
+		return docQuery, nil
+	}
+	if docQuery != nil {
+		//add must doc query
+		boolQuery.AddMust(docQuery)
+	}
+	return boolQuery, nil
+}
+
+//create nested bool query tree
+//like (A OR B) AND NOT (C AND D)
+func (f *Query) createQueryTree(
+	node *json.QueryNode) (query.Query, error) {
+	var (
+		subQuery query.Query
+		err error
+	)
+	//basic check
+	if node == nil {
+		return nil, errors.New("query tree node is nil")
+	}
+
+	//check leaf node
+	if node.Query != nil {
+		subQuery, err = f.buildQuery(node.Query)
+	}else if node.FilterField != nil {
+		subQuery, err = f.createFilterLeaf(node.FilterField)
 	}else{
-		//general search request
-		searchRequest = bleve.NewSearchRequest(docQuery)
+		subQuery, err = f.createBoolNode(node)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	//set node boost
+	if node.Boost > 0 {
+		if bq, ok := subQuery.(query.BoostableQuery); ok {
+			bq.SetBoost(node.Boost)
+		}
 	}
-	return searchRequest
+	return subQuery, nil
+}
+
+//create bool query of node clauses
+func (f *Query) createBoolNode(
+	node *json.QueryNode) (query.Query, error) {
+	//create sub queries of clauses
+	clauses := make([][]query.Query, 0)
+	for _, nodes := range [][]*json.QueryNode{node.Must, node.Should, node.MustNot, node.Filter} {
+		subQueries := make([]query.Query, 0)
+		for _, subNode := range nodes {
+			subQuery, err := f.createQueryTree(subNode)
+			if err != nil {
+				return nil, err
+			}
+			if subQuery == nil {
+				return nil, errors.New("query tree node has no any query")
+			}
+			subQueries = append(subQueries, subQuery)
+		}
+		clauses = append(clauses, subQueries)
+	}
+	must, should, mustNot, filter := clauses[0], clauses[1], clauses[2], clauses[3]
+	if len(must) + len(should) + len(mustNot) + len(filter) <= 0 {
+		return nil, errors.New("query tree node has no any clause")
+	}
+	if node.MinShouldMatch > len(should) {
+		return nil, fmt.Errorf("minShouldMatch %d more than should clauses", node.MinShouldMatch)
+	}
+
+	//init bool query
+	boolQuery := bleve.NewBooleanQuery()
+	boolQuery.AddMust(must...)
+	boolQuery.AddShould(should...)
+	boolQuery.AddMustNot(mustNot...)
+	if len(filter) > 0 {
+		//filter matched docs with constant score
+		//not(not(filter)) used as bleve has no constant score query
+		notFilter := bleve.NewBooleanQuery()
+		notFilter.AddMustNot(bleve.NewConjunctionQuery(filter...))
+		filterQuery := bleve.NewBooleanQuery()
+		filterQuery.AddMustNot(notFilter)
+		boolQuery.AddMust(filterQuery)
+	}
+	if node.MinShouldMatch > 0 {
+		boolQuery.SetMinShould(float64(node.MinShouldMatch))
+	}else if len(should) > 0 && len(must) + len(filter) <= 0 {
+		//only should, at least one matched
+		boolQuery.SetMinShould(1)
+	}
+	return boolQuery, nil
 }
 
 ////////////////////////////
@@ -230,10 +649,10 @@ func (f *Query) BuildSearchReq(
 ////////////////////////////
 
 func (f *Query) createFilterQuery(
-	opt *json.QueryOptJson) *query.BooleanQuery {
+	opt *json.QueryOptJson) (*query.BooleanQuery, error) {
 	//check
 	if opt.Filters == nil || len(opt.Filters) <= 0 {
-		return nil
+		return nil, nil
 	}
 
 	//init bool query
@@ -241,177 +660,355 @@ func (f *Query) createFilterQuery(
 
 	//add filter field and value
 	for _, filter := range opt.Filters {
-		//do sub query by kind
-		switch filter.Kind {
-		case define.FilterKindBoolean:
-			{
-				//match by boolean
-				boolVal, _ := filter.Val.(bool)
-				pg := bleve.NewBoolFieldQuery(boolVal)
-				pg.SetField(filter.Field)
-				if filter.IsExclude {
-					boolQuery.AddMustNot(pg)
-				}else{
-					if filter.IsMust {
-						boolQuery.AddMust(pg)
-					}else{
-						boolQuery.AddShould(pg)
-					}
-				}
+		pg, err := f.createFilterLeaf(filter)
+		if err != nil {
+			return nil, err
+		}
+		if pg == nil {
+			continue
+		}
+		if filter.IsExclude {
+			boolQuery.AddMustNot(pg)
+		}else{
+			if filter.IsMust {
+				boolQuery.AddMust(pg)
+			}else{
+				boolQuery.AddShould(pg)
 			}
-		case define.FilterKindMatch:
-			{
-				//match by condition
-				if filter.Terms == nil || len(filter.Terms) <= 0 {
-					//use value as terms
-					filter.Terms = []string{
-						fmt.Sprintf("%v", filter.Val),
-					}
-				}
-				subQueries := make([]query.Query, 0)
-				for _, v := range filter.Terms {
-					//multi terms
-					subPg := bleve.NewMatchQuery(v)
-					subPg.SetField(filter.Field)
-					subQueries = append(subQueries, subPg)
-				}
-				pg := bleve.NewDisjunctionQuery(subQueries...)
-				if filter.IsExclude {
-					boolQuery.AddMustNot(pg)
-				}else{
-					if filter.IsMust {
-						boolQuery.AddMust(pg)
-					}else{
-						boolQuery.AddShould(pg)
-					}
-				}
+		}
+	}
+	return boolQuery, nil
+}
+
+//create sub query of one filter by kind
+func (f *Query) createFilterLeaf(
+	filter *json.FilterField) (query.Query, error) {
+	var (
+		err error
+	)
+	//check multi fields
+	if len(filter.Fields) > 0 {
+		fieldsQuery := f.createFieldsQuery(filter.Fields, filter.MultiFieldKind, filter.TieBreaker, func(field string) query.Query {
+			subFilter := *filter
+			subFilter.Field = field
+			subFilter.Fields = nil
+			subQuery, subErr := f.createFilterLeaf(&subFilter)
+			if subErr != nil {
+				err = subErr
 			}
-		case define.FilterKindMatchRange:
-			{
-				//match by range
-				pg := bleve.NewTermRangeQuery(filter.MinVal, filter.MinVal)
-				pg.SetField(filter.Field)
-				if filter.IsExclude {
-					boolQuery.AddMustNot(pg)
-				}else{
-					if filter.IsMust {
-						boolQuery.AddMust(pg)
-					}else{
-						boolQuery.AddShould(pg)
-					}
+			return subQuery
+		})
+		if err != nil {
+			return nil, err
+		}
+		if fieldsQuery == nil {
+			return nil, fmt.Errorf("invalid filter of fields %v", filter.Fields)
+		}
+		return fieldsQuery, nil
+	}
+
+	switch filter.Kind {
+	case define.FilterKindBoolean:
+		{
+			//match by boolean
+			boolVal, _ := filter.Val.(bool)
+			pg := bleve.NewBoolFieldQuery(boolVal)
+			pg.SetField(filter.Field)
+			return pg, nil
+		}
+	case define.FilterKindMatch:
+		{
+			//match by condition
+			if filter.Terms == nil || len(filter.Terms) <= 0 {
+				//use value as terms
+				filter.Terms = []string{
+					fmt.Sprintf("%v", filter.Val),
 				}
 			}
-		case define.FilterKindPrefix:
-			{
-				pg := bleve.NewPrefixQuery(fmt.Sprintf("%v", filter.Val))
-				pg.SetField(filter.Field)
-				if filter.IsExclude {
-					boolQuery.AddMustNot(pg)
-				}else {
-					if filter.IsMust {
-						boolQuery.AddMust(pg)
-					} else {
-						boolQuery.AddShould(pg)
-					}
-				}
+			subQueries := make([]query.Query, 0)
+			for _, v := range filter.Terms {
+				//multi terms
+				subPg := bleve.NewMatchQuery(v)
+				subPg.SetField(filter.Field)
+				subQueries = append(subQueries, subPg)
 			}
-		case define.FilterKindPhraseQuery, define.FilterKindExcludePhraseQuery:
-			{
-				//sub terms phrase query
-				//match by condition
-				if filter.Terms == nil || len(filter.Terms) <= 0 {
-					//use value as terms
-					filter.Terms = []string{
-						fmt.Sprintf("%v", filter.Val),
-					}
-				}
-				pq := bleve.NewPhraseQuery(filter.Terms, filter.Field)
-				if filter.IsExclude {
-					boolQuery.AddMustNot(pq)
-				} else {
-					if filter.IsMust {
-						boolQuery.AddMust(pq)
-					}else{
-						boolQuery.AddShould(pq)
-					}
+			return bleve.NewDisjunctionQuery(subQueries...), nil
+		}
+	case define.FilterKindMatchRange:
+		{
+			//match by range
+			pg := bleve.NewTermRangeQuery(filter.MinVal, filter.MaxVal)
+			pg.SetField(filter.Field)
+			return pg, nil
+		}
+	case define.FilterKindPrefix:
+		{
+			pg := bleve.NewPrefixQuery(fmt.Sprintf("%v", filter.Val))
+			pg.SetField(filter.Field)
+			return pg, nil
+		}
+	case define.FilterKindPhraseQuery, define.FilterKindExcludePhraseQuery:
+		{
+			//sub terms phrase query
+			//match by condition
+			if filter.Terms == nil || len(filter.Terms) <= 0 {
+				//use value as terms
+				filter.Terms = []string{
+					fmt.Sprintf("%v", filter.Val),
 				}
 			}
-		case define.FilterKindNumericRange:
-			{
-				//min <= val < max
-				pg := bleve.NewNumericRangeQuery(&filter.MinFloatVal, &filter.MaxFloatVal)
-				pg.SetField(filter.Field)
-				if filter.IsExclude {
-					boolQuery.AddMustNot(pg)
-				}else{
-					if filter.IsMust {
-						boolQuery.AddMust(pg)
-					}else{
-						boolQuery.AddShould(pg)
-					}
+			return bleve.NewPhraseQuery(filter.Terms, filter.Field), nil
+		}
+	case define.FilterKindNumericRange:
+		{
+			//min <= val < max
+			pg := bleve.NewNumericRangeQuery(&filter.MinFloatVal, &filter.MaxFloatVal)
+			pg.SetField(filter.Field)
+			return pg, nil
+		}
+	case define.FilterKindDateRange:
+		{
+			pg := bleve.NewDateRangeQuery(filter.StartTime, filter.EndTime)
+			pg.SetField(filter.Field)
+			return pg, nil
+		}
+	case define.FilterKindSubDocIds:
+		{
+			return bleve.NewDocIDQuery(filter.DocIds), nil
+		}
+	case define.FilterKindTermsQuery:
+		{
+			//multi terms
+			subQueries := make([]query.Query, 0)
+			if filter.Terms == nil || len(filter.Terms) <= 0 {
+				//use value as terms
+				filter.Terms = []string{
+					fmt.Sprintf("%v", filter.Val),
 				}
 			}
-		case define.FilterKindDateRange:
-			{
-				pg := bleve.NewDateRangeQuery(filter.StartTime, filter.EndTime)
-				pg.SetField(filter.Field)
-				if filter.IsExclude {
-					boolQuery.AddMustNot(pg)
-				}else {
-					if filter.IsMust {
-						boolQuery.AddMust(pg)
-					} else {
-						boolQuery.AddShould(pg)
-					}
+			for _, v := range filter.Terms {
+				if v == "" {
+					continue
 				}
+				subPg := bleve.NewTermQuery(v)
+				subPg.SetField(filter.Field)
+				subQueries = append(subQueries, subPg)
 			}
-		case define.FilterKindSubDocIds:
-			{
-				pg := bleve.NewDocIDQuery(filter.DocIds)
-				if filter.IsExclude {
-					boolQuery.AddMustNot(pg)
-				}else{
-					if filter.IsMust {
-						boolQuery.AddMust(pg)
-					}else{
-						boolQuery.AddShould(pg)
-					}
+			//create disjunction query
+			//used for match batch terms match
+			return bleve.NewDisjunctionQuery(subQueries...), nil
+		}
+	case define.FilterKindFuzzy:
+		{
+			//typo tolerant term
+			pg := bleve.NewFuzzyQuery(fmt.Sprintf("%v", filter.Val))
+			pg.SetField(filter.Field)
+			pg.SetFuzziness(f.getFuzziness(filter.Fuzziness))
+			pg.SetPrefix(filter.PrefixLen)
+			return pg, nil
+		}
+	case define.FilterKindWildcard:
+		{
+			pg := bleve.NewWildcardQuery(fmt.Sprintf("%v", filter.Val))
+			pg.SetField(filter.Field)
+			return pg, nil
+		}
+	case define.FilterKindRegexp:
+		{
+			pg := bleve.NewRegexpQuery(fmt.Sprintf("%v", filter.Val))
+			pg.SetField(filter.Field)
+			return pg, nil
+		}
+	case define.FilterKindGeoDistance, define.FilterKindGeoBoundingBox, define.FilterKindGeoPolygon:
+		return f.createGeoFilter(filter)
+	case define.FilterKindRange:
+		return f.createRangeFilter(filter)
+	case define.FilterKindExists, define.FilterKindMissing:
+		{
+			//field has any indexed value or not
+			if filter.Field == "" {
+				return nil, errors.New("exists or missing filter need field")
+			}
+			existsQuery := newExistsQuery(filter.Field)
+			if filter.Kind == define.FilterKindExists {
+				return existsQuery, nil
+			}
+			pg := bleve.NewBooleanQuery()
+			pg.AddMust(bleve.NewMatchAllQuery())
+			pg.AddMustNot(existsQuery)
+			return pg, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid filter kind %d", filter.Kind)
+}
+
+//create range filter of number, date or term
+//nil bound means open
+func (f *Query) createRangeFilter(
+	filter *json.FilterField) (query.Query, error) {
+	//basic check
+	para := filter.Range
+	if para == nil || (para.Min == nil && para.Max == nil) {
+		return nil, fmt.Errorf("range filter of `%s` need min or max", filter.Field)
+	}
+	includeMin, includeMax := para.IncludeMin, para.IncludeMax
+
+	//create query by value type
+	switch para.Type {
+	case "", define.RangeTypeOfNumber:
+		{
+			var (
+				min, max *float64
+			)
+			if para.Min != nil {
+				num, ok := f.getRangeNumber(para.Min)
+				if !ok {
+					return nil, fmt.Errorf("invalid number `%v` of range filter `%s`", para.Min, filter.Field)
 				}
+				min = &num
 			}
-		case define.FilterKindTermsQuery:
-			{
-				//multi terms
-				subQueries := make([]query.Query, 0)
-				if filter.Terms == nil || len(filter.Terms) <= 0 {
-					//use value as terms
-					filter.Terms = []string{
-						fmt.Sprintf("%v", filter.Val),
-					}
+			if para.Max != nil {
+				num, ok := f.getRangeNumber(para.Max)
+				if !ok {
+					return nil, fmt.Errorf("invalid number `%v` of range filter `%s`", para.Max, filter.Field)
 				}
-				for _, v := range filter.Terms {
-					if v == "" {
-						continue
-					}
-					subPg := bleve.NewTermQuery(v)
-					subPg.SetField(filter.Field)
-					subQueries = append(subQueries, subPg)
+				max = &num
+			}
+			pg := bleve.NewNumericRangeInclusiveQuery(min, max, &includeMin, &includeMax)
+			pg.SetField(filter.Field)
+			return pg, nil
+		}
+	case define.RangeTypeOfDate:
+		{
+			var (
+				start, end time.Time
+			)
+			if para.Min != nil {
+				t, ok := f.getRangeTime(para.Min, para.DateFormats)
+				if !ok {
+					return nil, fmt.Errorf("invalid date `%v` of range filter `%s`", para.Min, filter.Field)
 				}
-				//create disjunction query
-				//used for match batch terms match
-				pg := bleve.NewDisjunctionQuery(subQueries...)
-				if filter.IsExclude {
-					boolQuery.AddMustNot(pg)
-				}else{
-					if filter.IsMust {
-						boolQuery.AddMust(pg)
-					}else{
-						boolQuery.AddShould(pg)
-					}
+				start = t
+			}
+			if para.Max != nil {
+				t, ok := f.getRangeTime(para.Max, para.DateFormats)
+				if !ok {
+					return nil, fmt.Errorf("invalid date `%v` of range filter `%s`", para.Max, filter.Field)
 				}
+				end = t
+			}
+			pg := bleve.NewDateRangeInclusiveQuery(start, end, &includeMin, &includeMax)
+			pg.SetField(filter.Field)
+			return pg, nil
+		}
+	case define.RangeTypeOfTerm:
+		{
+			var (
+				min, max string
+			)
+			if para.Min != nil {
+				min = fmt.Sprintf("%v", para.Min)
+			}
+			if para.Max != nil {
+				max = fmt.Sprintf("%v", para.Max)
+			}
+			pg := bleve.NewTermRangeInclusiveQuery(min, max, &includeMin, &includeMax)
+			pg.SetField(filter.Field)
+			return pg, nil
+		}
+	default:
+		return nil, fmt.Errorf("invalid range type `%s` of filter `%s`", para.Type, filter.Field)
+	}
+}
+
+//get number of range bound
+func (f *Query) getRangeNumber(val interface{}) (float64, bool) {
+	switch v := val.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		{
+			num, err := strconv.ParseFloat(v, 64)
+			return num, err == nil
+		}
+	}
+	return 0, false
+}
+
+//get time of range bound
+//string parsed by formats, number as unix seconds
+func (f *Query) getRangeTime(
+		val interface{},
+		formats []string,
+	) (time.Time, bool) {
+	switch v := val.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		return f.ParseTime(v, formats...)
+	}
+	if num, ok := f.getRangeNumber(val); ok {
+		//unix seconds, same as digit string
+		return time.Unix(0, int64(num * float64(time.Second))), true
+	}
+	return time.Time{}, false
+}
+
+//create geo filter of distance, bounding box or polygon
+//points support geo json, 'lat,lon', {lat, lon} and [lon, lat]
+func (f *Query) createGeoFilter(
+	filter *json.FilterField) (query.Query, error) {
+	//parse points
+	points := make([]geo.Point, 0, len(filter.Points))
+	for _, v := range filter.Points {
+		lon, lat, ok := f.ParseGeoPoint(v)
+		if !ok {
+			return nil, fmt.Errorf("invalid geo point `%v` of field `%s`", v, filter.Field)
+		}
+		points = append(points, geo.Point{Lon: lon, Lat: lat})
+	}
+
+	//create query by kind
+	switch filter.Kind {
+	case define.FilterKindGeoDistance:
+		{
+			//points[0] is center
+			if len(points) != 1 || filter.Distance == "" {
+				return nil, fmt.Errorf("geo distance filter of `%s` need one point and distance", filter.Field)
+			}
+			pg := bleve.NewGeoDistanceQuery(points[0].Lon, points[0].Lat, filter.Distance)
+			pg.SetField(filter.Field)
+			return pg, nil
+		}
+	case define.FilterKindGeoBoundingBox:
+		{
+			//points are top left and bottom right
+			if len(points) != 2 {
+				return nil, fmt.Errorf("geo bounding box filter of `%s` need two points", filter.Field)
+			}
+			pg := bleve.NewGeoBoundingBoxQuery(points[0].Lon, points[0].Lat, points[1].Lon, points[1].Lat)
+			pg.SetField(filter.Field)
+			return pg, nil
+		}
+	default:
+		{
+			//points are polygon vertexes
+			if len(points) < 3 {
+				return nil, fmt.Errorf("geo polygon filter of `%s` need three points at least", filter.Field)
 			}
+			pg := query.NewGeoBoundingPolygonQuery(points)
+			pg.SetField(filter.Field)
+			return pg, nil
 		}
 	}
-	return boolQuery
 }
 
 //////////////////////
@@ -420,56 +1017,90 @@ func (f *Query) createFilterQuery(
 
 func (f *Query) createPhraseQuery(
 	opt *json.QueryOptJson) query.Query {
-	subQuery := bleve.NewMatchPhraseQuery(opt.Key)
-	if opt.Fields != nil {
-		for _, field := range opt.Fields {
-			//set query field
-			subQuery.SetField(field)
-		}
-	}
-	return subQuery
+	return f.createFieldsQuery(opt.Fields, opt.MultiFieldKind, opt.TieBreaker, func(field string) query.Query {
+		subQuery := bleve.NewMatchPhraseQuery(opt.Key)
+		subQuery.SetField(field)
+		return subQuery
+	})
 }
 
 func (f *Query) createMatchQuery(
 	opt *json.QueryOptJson) query.Query {
-	subQuery := bleve.NewMatchQuery(opt.Key)
-	if opt.Fields != nil {
-		for _, field := range opt.Fields {
-			//set query field
-			subQuery.SetField(field)
-		}
-	}
-	return subQuery
+	return f.createFieldsQuery(opt.Fields, opt.MultiFieldKind, opt.TieBreaker, func(field string) query.Query {
+		subQuery := bleve.NewMatchQuery(opt.Key)
+		subQuery.SetField(field)
+		return subQuery
+	})
 }
 
 func (f *Query) createMatchPhraseQuery(
 	opt *json.QueryOptJson) query.Query {
-	subQuery := bleve.NewMatchPhraseQuery(opt.Key)
-	if opt.Fields != nil {
-		for _, field := range opt.Fields {
-			//set query field
-			subQuery.SetField(field)
-		}
-	}
-	return subQuery
+	return f.createFieldsQuery(opt.Fields, opt.MultiFieldKind, opt.TieBreaker, func(field string) query.Query {
+		subQuery := bleve.NewMatchPhraseQuery(opt.Key)
+		subQuery.SetField(field)
+		return subQuery
+	})
 }
 
 func (f *Query) createGeoDistanceQuery(
 	opt *json.QueryOptJson) query.Query {
-	subQuery := bleve.NewGeoDistanceQuery(opt.Lon, opt.Lat, opt.Distance)
-	if opt.Fields != nil {
-		for _, field := range opt.Fields {
-			//set query field
-			subQuery.SetField(field)
-		}
-	}
-	return subQuery
+	return f.createFieldsQuery(opt.Fields, opt.MultiFieldKind, opt.TieBreaker, func(field string) query.Query {
+		subQuery := bleve.NewGeoDistanceQuery(opt.Lon, opt.Lat, opt.Distance)
+		subQuery.SetField(field)
+		return subQuery
+	})
 }
 
 func (f *Query) createPrefixQuery(
 	opt *json.QueryOptJson) query.Query {
-	subQuery := bleve.NewPrefixQuery(opt.Key)
-	return subQuery
+	return f.createFieldsQuery(opt.Fields, opt.MultiFieldKind, opt.TieBreaker, func(field string) query.Query {
+		subQuery := bleve.NewPrefixQuery(opt.Key)
+		subQuery.SetField(field)
+		return subQuery
+	})
+}
+
+//query string syntax, like 'title:phone +cat:job-1 price:>10'
+//syntax error will be reported with failed position
+func (f *Query) createQueryStringQuery(
+	opt *json.QueryOptJson) (query.Query, error) {
+	subQuery := bleve.NewQueryStringQuery(opt.Key)
+	if _, err := subQuery.Parse(); err != nil {
+		pos, token := f.getQueryStringErrPos(opt.Key)
+		return nil, fmt.Errorf("query string syntax error at position %d near `%s`, %v",
+			pos, token, err)
+	}
+	return subQuery, nil
+}
+
+//typo tolerant match, key will be analyzed
+func (f *Query) createFuzzyQuery(
+	opt *json.QueryOptJson) query.Query {
+	return f.createFieldsQuery(opt.Fields, opt.MultiFieldKind, opt.TieBreaker, func(field string) query.Query {
+		subQuery := bleve.NewMatchQuery(opt.Key)
+		subQuery.SetField(field)
+		subQuery.SetFuzziness(f.getFuzziness(opt.Fuzziness))
+		subQuery.SetPrefix(opt.PrefixLen)
+		return subQuery
+	})
+}
+
+func (f *Query) createWildcardQuery(
+	opt *json.QueryOptJson) query.Query {
+	return f.createFieldsQuery(opt.Fields, opt.MultiFieldKind, opt.TieBreaker, func(field string) query.Query {
+		subQuery := bleve.NewWildcardQuery(opt.Key)
+		subQuery.SetField(field)
+		return subQuery
+	})
+}
+
+func (f *Query) createRegexpQuery(
+	opt *json.QueryOptJson) query.Query {
+	return f.createFieldsQuery(opt.Fields, opt.MultiFieldKind, opt.TieBreaker, func(field string) query.Query {
+		subQuery := bleve.NewRegexpQuery(opt.Key)
+		subQuery.SetField(field)
+		return subQuery
+	})
 }
 
 func (f *Query) createTermQuery(
@@ -483,44 +1114,368 @@ func (f *Query) createTermQuery(
 //private func
 ///////////////
 
+//get failed position of query string
+//the first token after longest valid prefix is the failed one
+//return rune position start from 0, failed token
+func (f *Query) getQueryStringErrPos(
+		queryStr string,
+	) (int, string) {
+	var (
+		failedPos, tokenStart int
+		failedToken string
+	)
+	runes := []rune(queryStr)
+	for i := 0; i <= len(runes); i++ {
+		if i < len(runes) && !unicode.IsSpace(runes[i]) {
+			continue
+		}
+		if i > tokenStart {
+			//check prefix end with current token
+			subQuery := bleve.NewQueryStringQuery(string(runes[:i]))
+			if _, err := subQuery.Parse(); err == nil {
+				failedPos, failedToken = i, ""
+			}else if failedToken == "" {
+				failedPos, failedToken = tokenStart, string(runes[tokenStart:i])
+			}
+		}
+		tokenStart = i + 1
+	}
+	if failedToken == "" {
+		//all prefixes valid, failed at end
+		failedPos = len(runes)
+	}
+	return failedPos, failedToken
+}
+
+//create query on multi fields, any field matched is ok
+//field support boost suffix, like 'title^3'
+//empty fields means default field, nil if no any sub query
+//tie breaker used for best fields, score of others matched fields added by it
+func (f *Query) createFieldsQuery(
+		fields []string,
+		multiFieldKind int,
+		tieBreaker float64,
+		cb func(field string) query.Query,
+	) query.Query {
+	//default field
+	if len(fields) <= 0 {
+		return cb("")
+	}
+
+	//create sub query of every field, nil skipped
+	subQueries := make([]query.Query, 0)
+	for _, field := range fields {
+		fieldName, boost := f.parseFieldBoost(field)
+		subQuery := cb(fieldName)
+		if subQuery == nil {
+			continue
+		}
+		if boost > 0 {
+			if bq, ok := subQuery.(query.BoostableQuery); ok {
+				bq.SetBoost(boost)
+			}
+		}
+		subQueries = append(subQueries, subQuery)
+	}
+	switch len(subQueries) {
+	case 0:
+		return nil
+	case 1:
+		return subQueries[0]
+	}
+
+	//combine by multi field kind
+	if multiFieldKind == define.MultiFieldKindOfMost {
+		//sum score of matched fields
+		return bleve.NewDisjunctionQuery(subQueries...)
+	}
+	//max score of matched fields
+	return newDisMaxQuery(tieBreaker, subQueries...)
+}
+
+//parse field with boost, like 'title^3'
+//return field name, boost
+func (f *Query) parseFieldBoost(field string) (string, float64) {
+	pos := strings.LastIndex(field, "^")
+	if pos <= 0 {
+		return field, 0
+	}
+	boost, err := strconv.ParseFloat(field[pos+1:], 64)
+	if err != nil || boost <= 0 {
+		return field, 0
+	}
+	return field[:pos], boost
+}
+
+//get fuzziness, use default if not set
+func (f *Query) getFuzziness(fuzziness int) int {
+	if fuzziness <= 0 {
+		return define.FuzzinessDefault
+	}
+	return fuzziness
+}
+
+//create sort order
+//doc id used as tie breaker for cursor paging
+func (f *Query) createSort(
+	sorts []*json.SortField) (search.SortOrder, error) {
+	var (
+		hasDocId bool
+	)
+	customSort := make(search.SortOrder, 0)
+	for _, sort := range sorts {
+		if sort == nil {
+			continue
+		}
+		switch {
+		case sort.Field == define.SortFieldOfScore:
+			customSort = append(customSort, &search.SortScore{Desc: sort.Desc})
+		case sort.Field == define.SortFieldOfId:
+			customSort = append(customSort, &search.SortDocID{Desc: sort.Desc})
+			hasDocId = true
+		case sort.GeoDistance != nil:
+			{
+				//sort by distance from point
+				geo := sort.GeoDistance
+				cs, err := search.NewSortGeoDistance(sort.Field, geo.Unit, geo.Lon, geo.Lat, sort.Desc)
+				if err != nil {
+					return nil, err
+				}
+				customSort = append(customSort, cs)
+			}
+		default:
+			{
+				cs := &search.SortField{
+					Field: sort.Field,
+					Desc: sort.Desc,
+				}
+				switch sort.Type {
+				case define.SortTypeOfString:
+					cs.Type = search.SortFieldAsString
+				case define.SortTypeOfNumber:
+					cs.Type = search.SortFieldAsNumber
+				case define.SortTypeOfDate:
+					cs.Type = search.SortFieldAsDate
+				}
+				switch sort.Mode {
+				case define.SortModeOfMin:
+					cs.Mode = search.SortFieldMin
+				case define.SortModeOfMax:
+					cs.Mode = search.SortFieldMax
+				}
+				if sort.Missing == define.SortMissingOfFirst {
+					cs.Missing = search.SortFieldMissingFirst
+				}
+				customSort = append(customSort, cs)
+			}
+		}
+	}
+	if len(customSort) <= 0 {
+		customSort = append(customSort, &search.SortScore{Desc: true})
+	}
+	if !hasDocId {
+		customSort = append(customSort, &search.SortDocID{})
+	}
+	return customSort, nil
+}
+
+//check need return docs or not
+func (f *Query) needDocs(opt *json.QueryOptJson) bool {
+	return opt.NeedDocs || len(opt.SourceIncludes) > 0
+}
+
+//set stored fields of search request for docs
+//only original doc json field loaded if stored
+func (f *Query) setDocFields(
+		idx bleve.Index,
+		searchRequest *bleve.SearchRequest,
+		opt *json.QueryOptJson,
+	) {
+	if !f.needDocs(opt) {
+		return
+	}
+	if f.HasSourceField(idx) {
+		searchRequest.Fields = append(searchRequest.Fields,
+			define.DocFieldOfSource, define.DocFieldOfBoost)
+	}else{
+		searchRequest.Fields = append(searchRequest.Fields, "*")
+	}
+}
+
+//get cursor of hit for search after or before
+//score sort key should be real score value
+func (f *Query) getCursor(
+		sortOrder search.SortOrder,
+		hit *search.DocumentMatch,
+	) []string {
+	cursor := make([]string, len(hit.Sort))
+	copy(cursor, hit.Sort)
+	for i, v := range sortOrder {
+		if i < len(cursor) && v.RequiresScoring() {
+			cursor[i] = strconv.FormatFloat(hit.Score, 'g', -1, 64)
+		}
+	}
+	return cursor
+}
+
+//check need rescore hits or not
+//only for sort by score and has score functions, doc boost applied in rescore too
+func (f *Query) needRescore(
+		opt *json.QueryOptJson,
+	) bool {
+	if len(opt.Sort) > 0 {
+		return false
+	}
+	return opt.Scoring != nil && len(opt.Scoring.Functions) > 0
+}
+
+//check need apply doc boost in query time or not
+//only for sort by score and index has boosted docs
+func (f *Query) needDocBoost(
+		idx bleve.Index,
+		opt *json.QueryOptJson,
+	) bool {
+	if len(opt.Sort) > 0 || opt.DisableBoost {
+		return false
+	}
+	val, err := idx.GetInternal([]byte(define.InterKeyOfBoosted))
+	if err != nil || val == nil {
+		return false
+	}
+	return true
+}
+
+//rescore hits with score functions and doc boost, and pick up current page
+func (f *Query) rescoreHits(
+		idx bleve.Index,
+		schema map[string]string,
+		opt *json.QueryOptJson,
+		hits search.DocumentMatchCollection,
+	) (search.DocumentMatchCollection, float64, error) {
+	var (
+		maxScore float64
+	)
+	//keep origin score for explanation
+	scores := make([]float64, len(hits))
+	for i, hit := range hits {
+		scores[i] = hit.Score
+	}
+
+	//apply score functions
+	if opt.Scoring != nil {
+		err := f.scorer.ApplyFunctions(idx, schema, opt.Scoring, hits)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	//apply doc boost
+	for _, hit := range hits {
+		if hit.Fields == nil || opt.DisableBoost {
+			continue
+		}
+		boost, ok := hit.Fields[define.DocFieldOfBoost].(float64)
+		if ok && boost > 0 {
+			hit.Score *= boost
+		}
+	}
+
+	//wrap explanation of changed score
+	for i, hit := range hits {
+		if hit.Expl != nil && hit.Score != scores[i] {
+			hit.Expl = &search.Explanation{
+				Value: hit.Score,
+				Message: "rescored by score functions and doc boost",
+				Children: []*search.Explanation{hit.Expl},
+			}
+		}
+	}
+
+	//sort by score desc
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Score > hits[j].Score
+	})
+	if len(hits) > 0 {
+		maxScore = hits[0].Score
+	}
+
+	//pick up current page
+	if opt.Offset >= len(hits) {
+		return search.DocumentMatchCollection{}, maxScore, nil
+	}
+	end := opt.Offset + opt.Size
+	if end > len(hits) {
+		end = len(hits)
+	}
+	return hits[opt.Offset:end], maxScore, nil
+}
+
+//set group key and inner hits of collapsed records
+func (f *Query) formatGroups(
+		idx iface.IIndex,
+		records []*json.HitDocJson,
+		groups []*collapseGroup,
+		opt *json.QueryOptJson,
+	) {
+	groupMap := make(map[string]*collapseGroup, len(groups))
+	for _, group := range groups {
+		groupMap[group.hits[0].ID] = group
+	}
+	for _, record := range records {
+		group, ok := groupMap[record.Id]
+		if !ok {
+			continue
+		}
+		record.GroupKey = group.key
+		if opt.Collapse.InnerSize > 0 {
+			record.InnerHits = f.formatResult(idx, &group.hits, opt)
+		}
+	}
+}
+
+//get took time since begin, in milliseconds
+func (f *Query) getTook(beginAt time.Time) float64 {
+	return float64(time.Since(beginAt).Microseconds()) / 1000
+}
+
 //format result
 func (f *Query) formatResult(
-		idx bleve.Index,
+		idx iface.IIndex,
 		hits *search.DocumentMatchCollection,
-		needDocs ...bool,
+		opt *json.QueryOptJson,
 	) []*json.HitDocJson {
 	var (
-		needDoc bool
-		doc index.Document
+		hitDocJson *json.HitDocJson
 		err error
 	)
 	//basic check
-	if idx == nil || hits == nil {
+	if idx == nil || hits == nil || opt == nil {
 		return nil
 	}
-	if needDocs != nil && len(needDocs) > 0 {
-		needDoc = needDocs[0]
-	}
+	needDoc := f.needDocs(opt)
+	highLightOpt := f.highlighter.GetOpt(opt)
 
 	//format result
 	result := make([]*json.HitDocJson, 0)
+	schema := idx.GetSchema()
 
 	//format records
 	for _, hit := range *hits {
+		//analyze hit with stored fields
 		if needDoc {
-			//get original doc
-			doc, err = idx.Document(hit.ID)
-			if err != nil {
-				continue
-			}
+			hitDocJson, err = f.AnalyzeHit(hit, schema, opt.SourceIncludes, opt.SourceExcludes)
+		}else{
+			hitDocJson, err = f.AnalyzeDoc(nil, hit)
 		}
-
-		//analyze doc
-		hitDocJson, subErr := f.AnalyzeDoc(doc, hit)
-		if subErr != nil || hitDocJson == nil {
+		if err != nil || hitDocJson == nil {
 			continue
 		}
 
+		//format high lights by opt
+		if highLightOpt != nil {
+			f.highlighter.Format(hitDocJson, hit.Fragments, highLightOpt)
+		}
+
 		//add into slice
 		result = append(result, hitDocJson)
 	}
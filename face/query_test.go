@@ -0,0 +1,281 @@
+package face
+
+import (
+	"fmt"
+	"github.com/andyzhou/tinysearch/define"
+	"github.com/andyzhou/tinysearch/iface"
+	"github.com/andyzhou/tinysearch/json"
+	"testing"
+)
+
+//init test manager with data path of temp dir
+func newTestManager(t *testing.T) *Manager {
+	m := NewManager(t.TempDir())
+	t.Cleanup(m.Quit)
+	return m
+}
+
+//get or create test index
+func newTestIndex(t *testing.T, m *Manager, tag string) iface.IIndex {
+	err := m.AddIndex(tag)
+	if err != nil {
+		t.Fatalf("add index %v failed, err:%v", tag, err)
+	}
+	index := m.GetIndex(tag)
+	if index == nil {
+		t.Fatalf("can't get index %v", tag)
+	}
+	return index
+}
+
+//add test docs
+func addTestDocs(t *testing.T, m *Manager, index iface.IIndex, docs map[string]map[string]interface{}) {
+	for docId, doc := range docs {
+		err := m.GetDoc().AddDoc(index, docId, doc)
+		if err != nil {
+			t.Fatalf("add doc %v failed, err:%v", docId, err)
+		}
+	}
+}
+
+//get doc count of index
+func getTestCount(t *testing.T, index iface.IIndex) uint64 {
+	indexer := index.AcquireIndex()
+	defer index.ReleaseIndex()
+	count, err := indexer.DocCount()
+	if err != nil {
+		t.Fatalf("get doc count failed, err:%v", err)
+	}
+	return count
+}
+
+//run query and return hit doc ids
+func runTestQuery(t *testing.T, m *Manager, index iface.IIndex, opt *json.QueryOptJson) []string {
+	opt.NoCache = true
+	if opt.Size <= 0 {
+		opt.Size = 100
+	}
+	result, err := m.GetQuery().Query(index, opt)
+	if err != nil {
+		t.Fatalf("query failed, err:%v", err)
+	}
+	ids := make([]string, 0)
+	if result == nil {
+		return ids
+	}
+	for _, v := range result.Records {
+		ids = append(ids, v.Id)
+	}
+	return ids
+}
+
+//test delete by query on every matched index of pattern tag
+func TestDeleteByQueryPattern(t *testing.T) {
+	m := newTestManager(t)
+	for i := 1; i <= 2; i++ {
+		index := newTestIndex(t, m, fmt.Sprintf("logs-%d", i))
+		addTestDocs(t, m, index, map[string]map[string]interface{}{
+			"1": {"cat": "old"},
+			"2": {"cat": "new"},
+		})
+	}
+	opt := json.NewQueryOptJson()
+	opt.QueryKind = define.QueryKindOfTerm
+	opt.TermPara.Field = "cat"
+	opt.TermPara.Val = "old"
+
+	//alias batch not support, should be rejected without panic
+	alias := m.GetQueryIndex("logs-*")
+	if alias == nil {
+		t.Fatal("can't get alias index")
+	}
+	_, err := m.GetQuery().DeleteByQuery(alias, opt)
+	if err == nil {
+		t.Fatal("delete by alias index should be rejected")
+	}
+
+	//delete on matched indexes one by one
+	indexes := m.GetMatchedIndexes("logs-*")
+	if len(indexes) != 2 {
+		t.Fatalf("matched indexes %v, expect 2", len(indexes))
+	}
+	for _, index := range indexes {
+		version := index.GetVersion()
+		deleted, subErr := m.GetQuery().DeleteByQuery(index, opt)
+		if subErr != nil {
+			t.Fatal(subErr)
+		}
+		if deleted != 1 {
+			t.Fatalf("deleted %v of %v, expect 1", deleted, index.GetTag())
+		}
+		if getTestCount(t, index) != 1 {
+			t.Fatalf("doc count of %v should be 1", index.GetTag())
+		}
+		if index.GetVersion() == version {
+			t.Fatalf("version of %v not changed", index.GetTag())
+		}
+	}
+}
+
+//test delete by query condition check
+func TestDeleteByQueryCondition(t *testing.T) {
+	m := newTestManager(t)
+	index := newTestIndex(t, m, "posts")
+	addTestDocs(t, m, index, map[string]map[string]interface{}{
+		"1": {"cat": "red"},
+		"2": {"cat": "green"},
+		"3": {"cat": "blue"},
+	})
+
+	//no any condition, rejected
+	_, err := m.GetQuery().DeleteByQuery(index, json.NewQueryOptJson())
+	if err == nil {
+		t.Fatal("delete without condition should be rejected")
+	}
+
+	//query tree only
+	opt := json.NewQueryOptJson()
+	opt.QueryKind = define.QueryKindOfConjunctionQuery
+	opt.QueryTree = &json.QueryNode{
+		Should: []*json.QueryNode{
+			{FilterField: &json.FilterField{Kind: define.FilterKindTermsQuery, Field: "cat", Val: "red"}},
+			{FilterField: &json.FilterField{Kind: define.FilterKindTermsQuery, Field: "cat", Val: "green"}},
+		},
+	}
+	deleted, err := m.GetQuery().DeleteByQuery(index, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 2 || getTestCount(t, index) != 1 {
+		t.Fatalf("deleted %v, expect 2", deleted)
+	}
+
+	//match all by query kind
+	opt = json.NewQueryOptJson()
+	opt.QueryKind = define.QueryKindOfMatchAll
+	deleted, err = m.GetQuery().DeleteByQuery(index, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 1 || getTestCount(t, index) != 0 {
+		t.Fatalf("deleted %v, expect 1", deleted)
+	}
+}
+
+//test query string syntax
+func TestQueryString(t *testing.T) {
+	m := newTestManager(t)
+	index := newTestIndex(t, m, "posts")
+	addTestDocs(t, m, index, map[string]map[string]interface{}{
+		"1": {"title": "red apple", "price": 10},
+		"2": {"title": "green apple", "price": 20},
+		"3": {"title": "red pear", "price": 30},
+	})
+	tests := map[string]int{
+		"title:apple":             2,
+		"+title:red -title:pear":  1,
+		`title:"green apple"`:     1,
+		"+title:apple +price:>15": 1,
+		"title:red title:green":   3,
+	}
+	for key, expect := range tests {
+		opt := json.NewQueryOptJson()
+		opt.QueryKind = define.QueryKindOfQueryString
+		opt.Key = key
+		ids := runTestQuery(t, m, index, opt)
+		if len(ids) != expect {
+			t.Fatalf("hits %v of `%v`, expect %v", ids, key, expect)
+		}
+	}
+
+	//syntax error with position
+	opt := json.NewQueryOptJson()
+	opt.QueryKind = define.QueryKindOfQueryString
+	opt.Key = `+title:"red apple`
+	_, err := m.GetQuery().Query(index, opt)
+	if err == nil {
+		t.Fatal("invalid query string should be rejected")
+	}
+}
+
+//test nested bool query tree
+func TestQueryTree(t *testing.T) {
+	m := newTestManager(t)
+	index := newTestIndex(t, m, "posts")
+	addTestDocs(t, m, index, map[string]map[string]interface{}{
+		"1": {"cat": "red", "tag": "hot"},
+		"2": {"cat": "green", "tag": "hot"},
+		"3": {"cat": "blue", "tag": "hot"},
+		"4": {"cat": "red", "tag": "cold"},
+	})
+	termNode := func(field, val string) *json.QueryNode {
+		return &json.QueryNode{
+			FilterField: &json.FilterField{Kind: define.FilterKindTermsQuery, Field: field, Val: val},
+		}
+	}
+
+	//(red OR green) AND hot AND NOT green
+	opt := json.NewQueryOptJson()
+	opt.QueryKind = define.QueryKindOfConjunctionQuery
+	opt.QueryTree = &json.QueryNode{
+		Must: []*json.QueryNode{
+			{Should: []*json.QueryNode{termNode("cat", "red"), termNode("cat", "green")}},
+		},
+		Filter:  []*json.QueryNode{termNode("tag", "hot")},
+		MustNot: []*json.QueryNode{termNode("cat", "green")},
+	}
+	ids := runTestQuery(t, m, index, opt)
+	if len(ids) != 1 || ids[0] != "1" {
+		t.Fatalf("hits %v, expect [1]", ids)
+	}
+
+	//min should match
+	opt.QueryTree = &json.QueryNode{
+		Should:         []*json.QueryNode{termNode("cat", "red"), termNode("tag", "hot")},
+		MinShouldMatch: 2,
+	}
+	ids = runTestQuery(t, m, index, opt)
+	if len(ids) != 1 || ids[0] != "1" {
+		t.Fatalf("hits %v of min should, expect [1]", ids)
+	}
+
+	//min should more than clauses
+	opt.QueryTree.MinShouldMatch = 3
+	_, err := m.GetQuery().Query(index, opt)
+	if err == nil {
+		t.Fatal("min should more than clauses should be rejected")
+	}
+}
+
+//test invalid filter kind rejected
+func TestQueryInvalidFilterKind(t *testing.T) {
+	m := newTestManager(t)
+	index := newTestIndex(t, m, "posts")
+	addTestDocs(t, m, index, map[string]map[string]interface{}{
+		"1": {"cat": "red"},
+	})
+
+	//leaf of query tree
+	opt := json.NewQueryOptJson()
+	opt.QueryKind = define.QueryKindOfConjunctionQuery
+	opt.QueryTree = &json.QueryNode{
+		Must: []*json.QueryNode{
+			{FilterField: &json.FilterField{Field: "cat", Val: "red"}},
+		},
+	}
+	_, err := m.GetQuery().Query(index, opt)
+	if err == nil {
+		t.Fatal("zero filter kind of query tree should be rejected")
+	}
+
+	//filter of multi fields
+	opt = json.NewQueryOptJson()
+	opt.QueryKind = define.QueryKindOfMatchAll
+	opt.Filters = []*json.FilterField{
+		{Kind: -1, Fields: []string{"cat", "tag"}, Val: "red", IsMust: true},
+	}
+	_, err = m.GetQuery().Query(index, opt)
+	if err == nil {
+		t.Fatal("invalid filter kind of multi fields should be rejected")
+	}
+}
@@ -0,0 +1,334 @@
+package face
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andyzhou/tinysearch/define"
+	"github.com/andyzhou/tinysearch/iface"
+	"github.com/andyzhou/tinysearch/json"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+ * face for rollover
+ * @author <AndyZhou>
+ * @mail <diudiu8848@163.com>
+ * - time based rolling index, like 'logs-2026.10.18'
+ * - rollover by age or doc count, like 'logs-2026.10.18-2'
+ * - current write tag cached, doc count checked by ticker, may be exceeded a little
+ * - drop expired index by retain days
+ */
+
+//inter data
+type (
+	rolloverState struct {
+		policy     *json.RolloverPolicyJson
+		date       string //current write date suffix
+		generation int    //current write generation, start from 1
+		createAt   time.Time
+		tag        string //current write tag, empty if not created
+		isFull     bool   //doc count of write index reached, set by ticker
+	}
+)
+
+//face info
+type Rollover struct {
+	manager  iface.IManager            //parent reference
+	policies map[string]*rolloverState //name -> state
+	Base
+	sync.RWMutex
+}
+
+//construct
+func NewRollover(manager iface.IManager) *Rollover {
+	//self init
+	this := &Rollover{
+		manager: manager,
+		policies: map[string]*rolloverState{},
+	}
+	return this
+}
+
+//add or replace policy
+//existing indexes of policy will be opened
+func (f *Rollover) AddPolicy(policy *json.RolloverPolicyJson) error {
+	//basic check
+	if policy == nil || policy.Name == "" ||
+		policy.MaxAge < 0 || policy.MaxDocs < 0 || policy.RetainDays < 0 {
+		return errors.New("invalid parameter")
+	}
+	if policy.DateFormat == "" {
+		policy.DateFormat = define.RolloverDateFormatDefault
+	}
+
+	//open existing indexes of policy
+	subDirs, _ := f.GetSubDirs(f.manager.GetDataPath())
+	for _, subDir := range subDirs {
+		if _, _, ok := f.parseTag(policy, subDir); !ok {
+			continue
+		}
+		if err := f.manager.AddIndex(subDir); err != nil {
+			log.Printf("tinysearch.Rollover:AddPolicy open index %v failed, err:%v", subDir, err)
+		}
+	}
+
+	//sync into map
+	f.Lock()
+	defer f.Unlock()
+	f.policies[policy.Name] = &rolloverState{
+		policy: policy,
+	}
+	return nil
+}
+
+//remove policy, indexes of policy will be kept
+func (f *Rollover) RemovePolicy(name string) error {
+	//basic check
+	if name == "" {
+		return errors.New("invalid parameter")
+	}
+	f.Lock()
+	defer f.Unlock()
+	if _, ok := f.policies[name]; !ok {
+		return errors.New("no such policy")
+	}
+	delete(f.policies, name)
+	return nil
+}
+
+//get policy by name
+func (f *Rollover) GetPolicy(name string) *json.RolloverPolicyJson {
+	f.RLock()
+	defer f.RUnlock()
+	v, ok := f.policies[name]
+	if !ok || v == nil {
+		return nil
+	}
+	return v.policy
+}
+
+//get current write tag of policy
+//rollover and create new index if need
+func (f *Rollover) GetWriteTag(name string) (string, error) {
+	//basic check
+	if name == "" {
+		return "", errors.New("invalid parameter")
+	}
+
+	//get cached write tag
+	now := time.Now()
+	f.RLock()
+	state, ok := f.policies[name]
+	if !ok || state == nil {
+		f.RUnlock()
+		return "", errors.New("no such policy")
+	}
+	tag := state.tag
+	isValid := tag != "" && !f.needRollover(state, now)
+	f.RUnlock()
+	if isValid && f.manager.GetIndex(tag) != nil {
+		return tag, nil
+	}
+
+	//check again with locker
+	f.Lock()
+	defer f.Unlock()
+	state, ok = f.policies[name]
+	if !ok || state == nil {
+		return "", errors.New("no such policy")
+	}
+	if state.tag != "" && !f.needRollover(state, now) &&
+		f.manager.GetIndex(state.tag) != nil {
+		return state.tag, nil
+	}
+
+	//check date
+	dateStr := now.Format(state.policy.DateFormat)
+	if state.date != dateStr {
+		//new date, continue with max existing generation
+		state.date = dateStr
+		state.generation = f.getMaxGeneration(state.policy, dateStr)
+		state.createAt = now
+	}else if state.tag != "" && f.needRollover(state, now) {
+		//rollover by age or doc count
+		state.generation++
+		state.createAt = now
+	}
+
+	//create index if need
+	tag = f.formatTag(state.policy, state.date, state.generation)
+	err := f.manager.AddIndex(tag)
+	if err != nil {
+		return "", err
+	}
+	state.tag = tag
+	state.isFull = false
+	return tag, nil
+}
+
+//check doc count of current write indexes
+//called by ticker, write index marked as full if reached max docs
+func (f *Rollover) CheckDocCount() {
+	//get write tags of policies with max docs
+	states := make(map[string]*rolloverState)
+	f.RLock()
+	for _, v := range f.policies {
+		if v.policy.MaxDocs > 0 && v.tag != "" && !v.isFull {
+			states[v.tag] = v
+		}
+	}
+	f.RUnlock()
+
+	//check doc count of opened write indexes
+	for tag, state := range states {
+		index := f.manager.GetIndex(tag)
+		if index == nil || !index.IsOpened() {
+			continue
+		}
+		indexer := index.AcquireIndex()
+		if indexer == nil {
+			index.ReleaseIndex()
+			continue
+		}
+		count, err := indexer.DocCount()
+		index.ReleaseIndex()
+		if err != nil || int64(count) < state.policy.MaxDocs {
+			continue
+		}
+		f.Lock()
+		if state.tag == tag {
+			state.isFull = true
+		}
+		f.Unlock()
+	}
+}
+
+//check and drop expired indexes
+func (f *Rollover) CheckRetention() {
+	//get policies with retain days
+	policies := make([]*json.RolloverPolicyJson, 0)
+	f.RLock()
+	for _, v := range f.policies {
+		if v.policy.RetainDays > 0 {
+			policies = append(policies, v.policy)
+		}
+	}
+	f.RUnlock()
+	if len(policies) <= 0 {
+		return
+	}
+
+	//check all indexes
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	tags := f.manager.GetIndexTags()
+	for _, policy := range policies {
+		expireAt := today.AddDate(0, 0, -policy.RetainDays)
+		for _, tag := range tags {
+			date, _, ok := f.parseTag(policy, tag)
+			if !ok || !date.Before(expireAt) {
+				continue
+			}
+			//drop expired index
+			err := f.manager.DropIndex(tag)
+			if err != nil {
+				log.Printf("tinysearch.Rollover:CheckRetention drop %v failed, err:%v", tag, err)
+			}
+		}
+	}
+}
+
+//////////////
+//private func
+//////////////
+
+//check write index need rollover or not
+//new date, age or doc count reached, should be called with locker
+func (f *Rollover) needRollover(
+		state *rolloverState,
+		now time.Time,
+	) bool {
+	//check date
+	if state.date != now.Format(state.policy.DateFormat) {
+		return true
+	}
+
+	//check age
+	if state.policy.MaxAge > 0 &&
+		now.Sub(state.createAt) >= time.Duration(state.policy.MaxAge) * time.Second {
+		return true
+	}
+
+	//check doc count, set by ticker
+	return state.isFull
+}
+
+//get max generation of date from opened indexes
+func (f *Rollover) getMaxGeneration(
+		policy *json.RolloverPolicyJson,
+		dateStr string,
+	) int {
+	maxGeneration := 1
+	prefix := fmt.Sprintf("%s-%s", policy.Name, dateStr)
+	for _, tag := range f.manager.GetIndexTags() {
+		if !strings.HasPrefix(tag, prefix) {
+			continue
+		}
+		_, generation, ok := f.parseTag(policy, tag)
+		if ok && generation > maxGeneration {
+			maxGeneration = generation
+		}
+	}
+	return maxGeneration
+}
+
+//format index tag
+func (f *Rollover) formatTag(
+		policy *json.RolloverPolicyJson,
+		dateStr string,
+		generation int,
+	) string {
+	if generation <= 1 {
+		return fmt.Sprintf("%s-%s", policy.Name, dateStr)
+	}
+	return fmt.Sprintf("%s-%s-%d", policy.Name, dateStr, generation)
+}
+
+//parse index tag
+//return date, generation, is matched
+func (f *Rollover) parseTag(
+		policy *json.RolloverPolicyJson,
+		tag string,
+	) (time.Time, int, bool) {
+	//check prefix
+	prefix := policy.Name + "-"
+	if !strings.HasPrefix(tag, prefix) {
+		return time.Time{}, 0, false
+	}
+	suffix := strings.TrimPrefix(tag, prefix)
+
+	//try parse as date
+	date, err := time.ParseInLocation(policy.DateFormat, suffix, time.Local)
+	if err == nil {
+		return date, 1, true
+	}
+
+	//try parse as date with generation
+	pos := strings.LastIndex(suffix, "-")
+	if pos <= 0 {
+		return time.Time{}, 0, false
+	}
+	generation, err := strconv.Atoi(suffix[pos+1:])
+	if err != nil || generation <= 1 {
+		return time.Time{}, 0, false
+	}
+	date, err = time.ParseInLocation(policy.DateFormat, suffix[:pos], time.Local)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	return date, generation, true
+}
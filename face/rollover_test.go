@@ -0,0 +1,92 @@
+package face
+
+import (
+	"fmt"
+	"github.com/andyzhou/tinysearch/define"
+	"github.com/andyzhou/tinysearch/json"
+	"testing"
+	"time"
+)
+
+//test write tag rolled over by doc count
+func TestRolloverByDocCount(t *testing.T) {
+	m := newTestManager(t)
+	err := m.AddRolloverPolicy(&json.RolloverPolicyJson{
+		Name: "logs",
+		MaxDocs: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	today := time.Now().Format(define.RolloverDateFormatDefault)
+
+	//first generation
+	index, err := m.GetOrAddIndex("logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index.GetTag() != "logs-" + today {
+		t.Fatalf("write tag %v, expect logs-%v", index.GetTag(), today)
+	}
+	addTestDocs(t, m, index, map[string]map[string]interface{}{
+		"1": {"title": "first"},
+		"2": {"title": "second"},
+	})
+
+	//not rolled over before doc count checked
+	index, err = m.GetOrAddIndex("logs")
+	if err != nil || index.GetTag() != "logs-" + today {
+		t.Fatalf("write tag should be kept before doc count checked")
+	}
+
+	//second generation
+	m.rollover.CheckDocCount()
+	index, err = m.GetOrAddIndex("logs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index.GetTag() != fmt.Sprintf("logs-%v-2", today) {
+		t.Fatalf("write tag %v, expect logs-%v-2", index.GetTag(), today)
+	}
+	addTestDocs(t, m, index, map[string]map[string]interface{}{
+		"3": {"title": "third"},
+	})
+
+	//query all indexes by policy name
+	alias := m.GetQueryIndex("logs")
+	if alias == nil {
+		t.Fatal("can't get query index of policy name")
+	}
+	opt := json.NewQueryOptJson()
+	opt.QueryKind = define.QueryKindOfMatchAll
+	ids := runTestQuery(t, m, alias, opt)
+	if len(ids) != 3 {
+		t.Fatalf("hits %v of all generations, expect 3", ids)
+	}
+}
+
+//test expired index dropped by retain days
+func TestRolloverRetention(t *testing.T) {
+	m := newTestManager(t)
+	err := m.AddRolloverPolicy(&json.RolloverPolicyJson{
+		Name: "logs",
+		RetainDays: 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	expired := "logs-" + now.AddDate(0, 0, -10).Format(define.RolloverDateFormatDefault)
+	kept := "logs-" + now.AddDate(0, 0, -1).Format(define.RolloverDateFormatDefault)
+	newTestIndex(t, m, expired)
+	newTestIndex(t, m, kept)
+	newTestIndex(t, m, "posts")
+
+	m.rollover.CheckRetention()
+	if m.GetIndex(expired) != nil {
+		t.Fatalf("expired index %v should be dropped", expired)
+	}
+	if m.GetIndex(kept) == nil || m.GetIndex("posts") == nil {
+		t.Fatal("not expired index should be kept")
+	}
+}
@@ -0,0 +1,491 @@
+package face
+
+import (
+	"errors"
+	"fmt"
+	"github.com/andyzhou/tinysearch/define"
+	"github.com/andyzhou/tinysearch/json"
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/geo"
+	"github.com/blevesearch/bleve/v2/search"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+ * face for score
+ * @author <AndyZhou>
+ * @mail <diudiu8848@163.com>
+ * - function score on top hits, like recency decay
+ * - decay of gauss, exp, linear on date, geo or numeric field
+ * - field value factor and weight per filter
+ */
+
+//decay field kind
+const (
+	decayKindOfNumeric = iota
+	decayKindOfDate
+	decayKindOfGeo
+)
+
+//inter decay para
+type decayPara struct {
+	kind   int
+	origin interface{} //float64, time.Time or [lon, lat]
+	scale  float64
+	offset float64
+	decay  float64
+}
+
+//face info
+type Score struct {
+	query *Query //reference
+	Base
+}
+
+//construct
+func NewScore(query *Query) *Score {
+	//self init
+	this := &Score{
+		query: query,
+	}
+	return this
+}
+
+//get stored fields need loaded for functions
+func (f *Score) GetFields(scoring *json.ScoringJson) []string {
+	result := make([]string, 0)
+	if scoring == nil {
+		return result
+	}
+	for _, fn := range scoring.Functions {
+		if fn != nil && fn.Field != "" {
+			result = append(result, fn.Field)
+		}
+	}
+	return result
+}
+
+//apply score functions on hits
+//hits score will be replaced with combined score
+func (f *Score) ApplyFunctions(
+		indexer bleve.Index,
+		schema map[string]string,
+		scoring *json.ScoringJson,
+		hits search.DocumentMatchCollection,
+	) error {
+	//basic check
+	if indexer == nil || scoring == nil {
+		return errors.New("invalid parameter")
+	}
+	if len(scoring.Functions) <= 0 || len(hits) <= 0 {
+		return nil
+	}
+
+	//prepare decay para and filter matched docs
+	decays := make([]*decayPara, len(scoring.Functions))
+	matches := make([]map[string]bool, len(scoring.Functions))
+	for i, fn := range scoring.Functions {
+		if fn == nil {
+			return errors.New("score function is nil")
+		}
+		switch fn.Kind {
+		case define.ScoreFuncOfGauss, define.ScoreFuncOfExp, define.ScoreFuncOfLinear:
+			{
+				para, err := f.parseDecayPara(fn, schema[fn.Field])
+				if err != nil {
+					return err
+				}
+				decays[i] = para
+			}
+		case define.ScoreFuncOfFieldValue, define.ScoreFuncOfWeight:
+		default:
+			return fmt.Errorf("invalid score function `%s`", fn.Kind)
+		}
+		if fn.Filter != nil {
+			matched, err := f.getFilterMatched(indexer, fn.Filter, hits)
+			if err != nil {
+				return err
+			}
+			matches[i] = matched
+		}
+	}
+
+	//calculate and combine scores of hit
+	for _, hit := range hits {
+		scores := make([]float64, 0)
+		for i, fn := range scoring.Functions {
+			if matches[i] != nil && !matches[i][hit.ID] {
+				continue
+			}
+			score := f.calcFunction(fn, decays[i], hit)
+			if fn.Weight > 0 {
+				score *= fn.Weight
+			}
+			scores = append(scores, score)
+		}
+		if len(scores) <= 0 {
+			//no function applied
+			continue
+		}
+		funcScore := f.combineScores(scoring.ScoreMode, scores)
+		hit.Score = f.combineBoost(scoring.BoostMode, hit.Score, funcScore)
+	}
+	return nil
+}
+
+//////////////
+//private func
+//////////////
+
+//calculate score of one function
+func (f *Score) calcFunction(
+		fn *json.ScoreFunction,
+		para *decayPara,
+		hit *search.DocumentMatch,
+	) float64 {
+	switch fn.Kind {
+	case define.ScoreFuncOfWeight:
+		return 1
+	case define.ScoreFuncOfFieldValue:
+		{
+			value, ok := f.getNumericValue(hit.Fields[fn.Field])
+			if !ok {
+				value = fn.Missing
+			}
+			factor := fn.Factor
+			if factor == 0 {
+				factor = 1
+			}
+			return f.applyModifier(fn.Modifier, value * factor)
+		}
+	default:
+		{
+			//decay functions, use min distance of values
+			distance, ok := f.getDecayDistance(para, hit.Fields[fn.Field])
+			if !ok {
+				return 1
+			}
+			distance = math.Max(0, distance - para.offset)
+			switch fn.Kind {
+			case define.ScoreFuncOfGauss:
+				return math.Exp(distance * distance * math.Log(para.decay) / (para.scale * para.scale))
+			case define.ScoreFuncOfExp:
+				return math.Exp(distance * math.Log(para.decay) / para.scale)
+			default:
+				s := para.scale / (1 - para.decay)
+				return math.Max(0, (s - distance) / s)
+			}
+		}
+	}
+}
+
+//combine function scores
+func (f *Score) combineScores(mode string, scores []float64) float64 {
+	result := scores[0]
+	switch mode {
+	case define.ScoreModeOfFirst:
+		return result
+	case define.ScoreModeOfSum, define.ScoreModeOfAvg:
+		{
+			for _, v := range scores[1:] {
+				result += v
+			}
+			if mode == define.ScoreModeOfAvg {
+				result /= float64(len(scores))
+			}
+		}
+	case define.ScoreModeOfMax:
+		for _, v := range scores[1:] {
+			result = math.Max(result, v)
+		}
+	case define.ScoreModeOfMin:
+		for _, v := range scores[1:] {
+			result = math.Min(result, v)
+		}
+	default:
+		for _, v := range scores[1:] {
+			result *= v
+		}
+	}
+	return result
+}
+
+//combine query score with function score
+func (f *Score) combineBoost(mode string, score, funcScore float64) float64 {
+	switch mode {
+	case define.ScoreModeOfReplace:
+		return funcScore
+	case define.ScoreModeOfSum:
+		return score + funcScore
+	case define.ScoreModeOfAvg:
+		return (score + funcScore) / 2
+	case define.ScoreModeOfMax:
+		return math.Max(score, funcScore)
+	case define.ScoreModeOfMin:
+		return math.Min(score, funcScore)
+	default:
+		return score * funcScore
+	}
+}
+
+//apply field value modifier
+func (f *Score) applyModifier(modifier string, value float64) float64 {
+	switch modifier {
+	case "log":
+		value = math.Log10(value)
+	case "log1p":
+		value = math.Log10(value + 1)
+	case "log2p":
+		value = math.Log10(value + 2)
+	case "ln":
+		value = math.Log(value)
+	case "ln1p":
+		value = math.Log1p(value)
+	case "ln2p":
+		value = math.Log(value + 2)
+	case "sqrt":
+		value = math.Sqrt(value)
+	case "square":
+		value = value * value
+	case "reciprocal":
+		value = 1 / value
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0
+	}
+	return value
+}
+
+//get docs matched filter in hits
+func (f *Score) getFilterMatched(
+		indexer bleve.Index,
+		filter *json.QueryNode,
+		hits search.DocumentMatchCollection,
+	) (map[string]bool, error) {
+	//create filter query in hits
+	filterQuery, err := f.query.createQueryTree(filter)
+	if err != nil {
+		return nil, err
+	}
+	docIds := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		docIds = append(docIds, hit.ID)
+	}
+	searchRequest := bleve.NewSearchRequestOptions(
+		bleve.NewConjunctionQuery(filterQuery, bleve.NewDocIDQuery(docIds)),
+		len(docIds), 0, false)
+	searchRequest.Score = "none"
+
+	//search matched docs
+	searchResult, err := indexer.Search(searchRequest)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]bool)
+	for _, hit := range searchResult.Hits {
+		result[hit.ID] = true
+	}
+	return result, nil
+}
+
+//parse decay para of function
+//origin parsed by field type of schema, guessed if unknown
+func (f *Score) parseDecayPara(
+		fn *json.ScoreFunction,
+		fieldType string,
+	) (*decayPara, error) {
+	var (
+		err error
+	)
+	//basic check
+	if fn.Field == "" || fn.Origin == "" || fn.Scale == "" {
+		return nil, fmt.Errorf("decay function `%s` need field, origin and scale", fn.Kind)
+	}
+	para := &decayPara{
+		decay: fn.Decay,
+	}
+	if para.decay <= 0 || para.decay >= 1 {
+		para.decay = define.ScoreDecayDefault
+	}
+
+	//parse origin, check field kind
+	err = f.parseDecayOrigin(para, fn, fieldType)
+	if err != nil {
+		return nil, err
+	}
+
+	//parse scale and offset
+	para.scale, err = f.parseDecayUnit(para.kind, fn.Scale)
+	if err != nil || para.scale <= 0 {
+		return nil, fmt.Errorf("invalid decay scale `%s`", fn.Scale)
+	}
+	if fn.Offset != "" {
+		para.offset, err = f.parseDecayUnit(para.kind, fn.Offset)
+		if err != nil {
+			return nil, fmt.Errorf("invalid decay offset `%s`", fn.Offset)
+		}
+	}
+	return para, nil
+}
+
+//parse decay origin by field type of schema
+//number origin of datetime field as unix seconds
+//number field with time origin treated as unix seconds
+func (f *Score) parseDecayOrigin(
+		para *decayPara,
+		fn *json.ScoreFunction,
+		fieldType string,
+	) error {
+	//check by field type
+	switch fieldType {
+	case define.FieldTypeOfDateTime:
+		{
+			originTime, ok := f.ParseTime(fn.Origin)
+			if !ok {
+				return fmt.Errorf("decay origin `%s` of datetime field `%s` should be time", fn.Origin, fn.Field)
+			}
+			para.kind = decayKindOfDate
+			para.origin = originTime
+			return nil
+		}
+	case define.FieldTypeOfGeoPoint:
+		{
+			lon, lat, ok := geo.ExtractGeoPoint(fn.Origin)
+			if !ok || !strings.Contains(fn.Origin, ",") {
+				return fmt.Errorf("decay origin `%s` of geo field `%s` should be 'lat,lon'", fn.Origin, fn.Field)
+			}
+			para.kind = decayKindOfGeo
+			para.origin = []float64{lon, lat}
+			return nil
+		}
+	case define.FieldTypeOfText, define.FieldTypeOfBool:
+		return fmt.Errorf("decay function not support %s field `%s`", fieldType, fn.Field)
+	}
+
+	//number field or unknown, guess by origin
+	if originVal, err := strconv.ParseFloat(fn.Origin, 64); err == nil {
+		para.kind = decayKindOfNumeric
+		para.origin = originVal
+	}else if originTime, ok := f.ParseTime(fn.Origin); ok {
+		para.kind = decayKindOfDate
+		para.origin = originTime
+	}else if lon, lat, ok := geo.ExtractGeoPoint(fn.Origin); ok && strings.Contains(fn.Origin, ",") && fieldType == "" {
+		para.kind = decayKindOfGeo
+		para.origin = []float64{lon, lat}
+	}else{
+		return fmt.Errorf("invalid decay origin `%s`", fn.Origin)
+	}
+	return nil
+}
+
+//parse scale or offset by field kind
+//date as seconds, geo as meters
+func (f *Score) parseDecayUnit(kind int, val string) (float64, error) {
+	switch kind {
+	case decayKindOfDate:
+		{
+			//support day and week suffix
+			for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+				if strings.HasSuffix(val, suffix) {
+					num, err := strconv.ParseFloat(strings.TrimSuffix(val, suffix), 64)
+					if err != nil {
+						return 0, err
+					}
+					return num * unit.Seconds(), nil
+				}
+			}
+			duration, err := time.ParseDuration(val)
+			if err != nil {
+				return 0, err
+			}
+			return duration.Seconds(), nil
+		}
+	case decayKindOfGeo:
+		return geo.ParseDistance(val)
+	default:
+		return strconv.ParseFloat(val, 64)
+	}
+}
+
+//get min distance of field values from origin
+func (f *Score) getDecayDistance(
+		para *decayPara,
+		value interface{},
+	) (float64, bool) {
+	var (
+		distance float64
+		found bool
+	)
+	//get all values, geo point may be slice
+	values := []interface{}{value}
+	if v, ok := value.([]interface{}); ok {
+		_, _, isPoint := geo.ExtractGeoPoint(v)
+		if para.kind != decayKindOfGeo || !isPoint {
+			values = v
+		}
+	}
+
+	//calculate distance one by one
+	for _, v := range values {
+		var (
+			subDistance float64
+		)
+		switch para.kind {
+		case decayKindOfDate:
+			{
+				//number value as unix seconds, like 'createAt' of int64
+				var (
+					t time.Time
+				)
+				if num, ok := v.(float64); ok {
+					t = time.Unix(0, int64(num * float64(time.Second)))
+				}else if str, ok := v.(string); ok {
+					if t, ok = f.ParseTime(str); !ok {
+						continue
+					}
+				}else{
+					continue
+				}
+				subDistance = math.Abs(t.Sub(para.origin.(time.Time)).Seconds())
+			}
+		case decayKindOfGeo:
+			{
+				lon, lat, ok := geo.ExtractGeoPoint(v)
+				if !ok {
+					continue
+				}
+				origin := para.origin.([]float64)
+				subDistance = geo.Haversin(lon, lat, origin[0], origin[1]) * 1000
+			}
+		default:
+			{
+				num, ok := f.getNumericValue(v)
+				if !ok {
+					continue
+				}
+				subDistance = math.Abs(num - para.origin.(float64))
+			}
+		}
+		if !found || subDistance < distance {
+			distance = subDistance
+			found = true
+		}
+	}
+	return distance, found
+}
+
+//get numeric value, use first value for multi values
+func (f *Score) getNumericValue(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case []interface{}:
+		if len(v) > 0 {
+			return f.getNumericValue(v[0])
+		}
+	}
+	return 0, false
+}
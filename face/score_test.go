@@ -0,0 +1,55 @@
+package face
+
+import (
+	"fmt"
+	"github.com/andyzhou/tinysearch/define"
+	"github.com/andyzhou/tinysearch/json"
+	"testing"
+	"time"
+)
+
+//test decay origin parsed by field type
+func TestScoreDecayOrigin(t *testing.T) {
+	m := newTestManager(t)
+	index := newTestIndex(t, m, "posts")
+	near := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	far := near.AddDate(0, 0, -30)
+	addTestDocs(t, m, index, map[string]map[string]interface{}{
+		"1": {"title": "apple", "publishAt": far.Format(time.RFC3339)},
+		"2": {"title": "apple", "publishAt": near.Format(time.RFC3339)},
+	})
+	newOpt := func(origin string) *json.QueryOptJson {
+		opt := json.NewQueryOptJson()
+		opt.QueryKind = define.QueryKindOfMatchAll
+		opt.Scoring = &json.ScoringJson{
+			Functions: []*json.ScoreFunction{
+				{Kind: define.ScoreFuncOfGauss, Field: "publishAt", Origin: origin, Scale: "7d"},
+			},
+			BoostMode: define.ScoreModeOfReplace,
+		}
+		return opt
+	}
+
+	//number origin of datetime field as unix seconds
+	for _, origin := range []string{fmt.Sprintf("%d", near.Unix()), near.Format(time.RFC3339)} {
+		opt := newOpt(origin)
+		opt.NoCache = true
+		opt.Size = 10
+		result, err := m.GetQuery().Query(index, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		records := result.Records
+		if len(records) != 2 || records[0].Id != "2" || records[0].Score <= records[1].Score {
+			t.Fatalf("origin %v, expect doc 2 scored higher", origin)
+		}
+	}
+
+	//origin mismatched with field type
+	for _, origin := range []string{"1.5", "30.1,120.2"} {
+		_, err := m.GetQuery().Query(index, newOpt(origin))
+		if err == nil {
+			t.Fatalf("origin %v of datetime field should be rejected", origin)
+		}
+	}
+}
@@ -0,0 +1,453 @@
+package face
+
+import (
+	"errors"
+	"github.com/andyzhou/tinysearch/define"
+	"github.com/andyzhou/tinysearch/json"
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis"
+	index "github.com/blevesearch/bleve_index_api"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+/*
+ * face for spell suggestion
+ * @author <AndyZhou>
+ * @mail <diudiu8848@163.com>
+ * - 'did you mean', corrected terms from dict of searched fields
+ * - candidates ranked by doc frequency, then edit distance and same length
+ * - single chinese char of jieba tokens merged with neighbor, like '交换' + '鸡'
+ * - pinyin similar candidates need pinyin hook, no pinyin table inside
+ */
+
+//inter span of key
+type spellSpan struct {
+	start, end int
+	text       string
+	misspelled bool
+	candidates []*spellCandidate
+}
+
+//inter corrected candidate
+type spellCandidate struct {
+	term     string
+	freq     uint64
+	distance int
+	pinyin   bool //same pinyin with original
+}
+
+//face info
+type Spell struct {
+	pinyinHook func(word string) string //convert chinese word into pinyin
+	Base
+}
+
+//construct
+func NewSpell() *Spell {
+	//self init
+	this := &Spell{}
+	return this
+}
+
+//set pinyin hook
+//used for pinyin similar candidates of chinese word, like '手鸡' -> '手机'
+func (f *Spell) SetHookForPinyin(hook func(word string) string) {
+	f.pinyinHook = hook
+}
+
+//get spell opt of query
+//return nil if not need spell check
+func (f *Spell) GetOpt(opt *json.QueryOptJson) *json.SpellJson {
+	if opt == nil {
+		return nil
+	}
+	if opt.SpellOpt != nil {
+		return opt.SpellOpt
+	}
+	if opt.SpellCheck {
+		return json.NewSpellJson()
+	}
+	return nil
+}
+
+//suggest corrected keys
+//fields are dict fields, default field used if empty
+func (f *Spell) Suggest(
+		indexer bleve.Index,
+		key string,
+		fields []string,
+		opt *json.SpellJson,
+	) ([]*json.SpellSuggestJson, error) {
+	//basic check
+	if indexer == nil || key == "" || opt == nil {
+		return nil, errors.New("invalid parameter")
+	}
+	if len(opt.Fields) > 0 {
+		fields = opt.Fields
+	}
+	if len(fields) <= 0 {
+		field, err := f.getDefaultField(indexer)
+		if err != nil {
+			return nil, err
+		}
+		fields = []string{field}
+	}
+	minFreq := opt.MinFreq
+	if minFreq <= 0 {
+		minFreq = 1
+	}
+
+	//get readers of indexes
+	readers, err := f.getReaders(indexer)
+	defer func() {
+		for _, reader := range readers {
+			reader.Close()
+		}
+	}()
+	if err != nil {
+		return nil, err
+	}
+
+	//analyze key into spans
+	tokens, err := f.analyzeText(indexer, key, fields[0])
+	if err != nil {
+		return nil, err
+	}
+	spans := f.createSpans(key, tokens, func(term string) uint64 {
+		return f.getTermFreq(readers, fields, term)
+	}, minFreq)
+
+	//get candidates of misspelled spans
+	misspelled := 0
+	for _, span := range spans {
+		if !span.misspelled {
+			continue
+		}
+		misspelled++
+		span.candidates = f.getCandidates(readers, fields, span.text, opt.Fuzziness, minFreq)
+	}
+	if misspelled <= 0 {
+		return nil, nil
+	}
+	return f.createSuggests(key, spans, opt.Size), nil
+}
+
+//////////////
+//private func
+//////////////
+
+//create suggestions of corrected spans
+//best candidates first, then replace one span by other candidates
+func (f *Spell) createSuggests(
+		key string,
+		spans []*spellSpan,
+		size int,
+	) []*json.SpellSuggestJson {
+	if size <= 0 {
+		size = define.SpellSuggestSize
+	}
+
+	//create suggest by picked candidate of spans
+	result := make([]*json.SpellSuggestJson, 0)
+	addSuggest := func(picks map[*spellSpan]*spellCandidate) {
+		suggest := json.NewSpellSuggestJson()
+		builder := strings.Builder{}
+		last := 0
+		for _, span := range spans {
+			pick, ok := picks[span]
+			if !ok {
+				continue
+			}
+			builder.WriteString(key[last:span.start])
+			builder.WriteString(pick.term)
+			last = span.end
+			suggest.Terms[span.text] = pick.term
+			if suggest.Freq == 0 || pick.freq < suggest.Freq {
+				suggest.Freq = pick.freq
+			}
+		}
+		if len(suggest.Terms) <= 0 {
+			return
+		}
+		builder.WriteString(key[last:])
+		suggest.Key = builder.String()
+		for _, v := range result {
+			if v.Key == suggest.Key {
+				return
+			}
+		}
+		result = append(result, suggest)
+	}
+
+	//best candidates of all spans
+	bestPicks := make(map[*spellSpan]*spellCandidate)
+	for _, span := range spans {
+		if len(span.candidates) > 0 {
+			bestPicks[span] = span.candidates[0]
+		}
+	}
+	addSuggest(bestPicks)
+
+	//other candidates of one span
+	for _, span := range spans {
+		for i := 1; i < len(span.candidates); i++ {
+			picks := make(map[*spellSpan]*spellCandidate)
+			for k, v := range bestPicks {
+				picks[k] = v
+			}
+			picks[span] = span.candidates[i]
+			addSuggest(picks)
+		}
+	}
+
+	//sort by freq desc, keep best one first
+	if len(result) > 1 {
+		others := result[1:]
+		sort.SliceStable(others, func(i, j int) bool {
+			return others[i].Freq > others[j].Freq
+		})
+	}
+	if len(result) > size {
+		result = result[:size]
+	}
+	return result
+}
+
+//get corrected candidates of term from field dicts
+func (f *Spell) getCandidates(
+		readers []index.IndexReader,
+		fields []string,
+		term string,
+		fuzziness int,
+		minFreq uint64,
+	) []*spellCandidate {
+	var (
+		pinyin string
+	)
+	//get fuzziness by term length
+	isCJK := f.isCJKWord(term)
+	if fuzziness <= 0 {
+		fuzziness = f.getFuzziness(term, isCJK)
+	}
+	if fuzziness <= 0 {
+		return nil
+	}
+	if isCJK && f.pinyinHook != nil {
+		//more candidates for pinyin check
+		pinyin = f.pinyinHook(term)
+		fuzziness = 2
+	}
+	if fuzziness > 2 {
+		fuzziness = 2
+	}
+
+	//get candidates from dicts
+	freqs := make(map[string]uint64)
+	for _, reader := range readers {
+		fuzzyReader, ok := reader.(index.IndexReaderFuzzy)
+		if !ok {
+			continue
+		}
+		for _, field := range fields {
+			dict, err := fuzzyReader.FieldDictFuzzy(field, term, fuzziness, "")
+			if err != nil {
+				continue
+			}
+			f.readDict(dict, freqs)
+		}
+	}
+
+	//filter candidates
+	termLen := utf8.RuneCountInString(term)
+	candidates := make([]*spellCandidate, 0)
+	for word, freq := range freqs {
+		if word == term || freq < minFreq {
+			continue
+		}
+		candidate := &spellCandidate{
+			term: word,
+			freq: freq,
+			distance: f.getDistance(term, word),
+		}
+		if pinyin != "" {
+			candidate.pinyin = f.pinyinHook(word) == pinyin
+			if !candidate.pinyin && candidate.distance > 1 {
+				continue
+			}
+		}
+		if isCJK && utf8.RuneCountInString(word) != termLen &&
+			!candidate.pinyin {
+			//chinese word with different length seldom be misspelled one
+			if candidate.distance > 1 || utf8.RuneCountInString(word) < 2 {
+				continue
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	//sort by pinyin, freq, distance and length
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.pinyin != b.pinyin {
+			return a.pinyin
+		}
+		if a.freq != b.freq {
+			return a.freq > b.freq
+		}
+		if a.distance != b.distance {
+			return a.distance < b.distance
+		}
+		aSame := utf8.RuneCountInString(a.term) == termLen
+		bSame := utf8.RuneCountInString(b.term) == termLen
+		if aSame != bSame {
+			return aSame
+		}
+		return a.term < b.term
+	})
+	if len(candidates) > define.SpellCandidateMax {
+		candidates = candidates[:define.SpellCandidateMax]
+	}
+	return candidates
+}
+
+//create spans of key by tokens
+func (f *Spell) createSpans(
+		key string,
+		tokens analysis.TokenStream,
+		getFreq func(term string) uint64,
+		minFreq uint64,
+	) []*spellSpan {
+	//skip tokens inside others, like '交换' of '交换机'
+	words := make([]*analysis.Token, 0, len(tokens))
+	for _, token := range tokens {
+		if !f.isWord(string(token.Term)) {
+			continue
+		}
+		inside := false
+		for _, v := range tokens {
+			if v != token && v.Start <= token.Start && token.End <= v.End &&
+				v.End - v.Start > token.End - token.Start {
+				inside = true
+				break
+			}
+		}
+		if !inside {
+			words = append(words, token)
+		}
+	}
+	sort.SliceStable(words, func(i, j int) bool {
+		return words[i].Start < words[j].Start
+	})
+
+	//skip tokens overlapped with previous one, like search mode of jieba
+	kept := words[:0]
+	for _, token := range words {
+		if n := len(kept); n > 0 && token.Start < kept[n-1].End {
+			continue
+		}
+		kept = append(kept, token)
+	}
+	words = kept
+
+	//create spans, single chinese char merged with neighbor
+	spans := make([]*spellSpan, 0, len(words))
+	for i, token := range words {
+		term := string(token.Term)
+		if getFreq(term) >= minFreq {
+			spans = append(spans, &spellSpan{
+				start: token.Start,
+				end: token.End,
+				text: term,
+			})
+			continue
+		}
+		span := &spellSpan{
+			start: token.Start,
+			end: token.End,
+			text: term,
+			misspelled: true,
+		}
+		if f.isCJKWord(term) && utf8.RuneCountInString(term) == 1 {
+			if n := len(spans); n > 0 && spans[n-1].end == token.Start &&
+				f.isCJKWord(spans[n-1].text) {
+				//merge with previous
+				last := spans[n-1]
+				last.end = token.End
+				last.text = key[last.start:last.end]
+				last.misspelled = true
+				continue
+			}
+			if i + 1 < len(words) && words[i+1].Start == token.End &&
+				f.isCJKWord(string(words[i+1].Term)) {
+				//merge with next
+				next := words[i+1]
+				next.Start = token.Start
+				next.Term = []byte(key[token.Start:next.End])
+				continue
+			}
+		}
+		spans = append(spans, span)
+	}
+	return spans
+}
+
+//get default fuzziness by term length
+//short latin term not corrected
+func (f *Spell) getFuzziness(term string, isCJK bool) int {
+	size := utf8.RuneCountInString(term)
+	if isCJK {
+		if size >= 4 {
+			return 2
+		}
+		return 1
+	}
+	switch {
+	case size <= 2:
+		return 0
+	case size <= 5:
+		return 1
+	default:
+		return 2
+	}
+}
+
+//get edit distance of runes
+func (f *Spell) getDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if prev[j] + 1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1] + 1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+//check term is chinese word or not
+func (f *Spell) isCJKWord(term string) bool {
+	for _, r := range term {
+		if !unicode.Is(unicode.Han, r) {
+			return false
+		}
+	}
+	return term != ""
+}
@@ -0,0 +1,42 @@
+package face
+
+import (
+	"github.com/blevesearch/bleve/v2/analysis"
+	"testing"
+)
+
+//test overlapped tokens of key, like search mode of jieba
+func TestSpellOverlappedSpans(t *testing.T) {
+	spell := NewSpell()
+	key := "abcdef ghij"
+	tokens := analysis.TokenStream{
+		{Term: []byte("abcd"), Start: 0, End: 4},
+		{Term: []byte("cdef"), Start: 2, End: 6},
+		{Term: []byte("ghij"), Start: 7, End: 11},
+	}
+	spans := spell.createSpans(key, tokens, func(term string) uint64 {
+		return 0
+	}, 1)
+	if len(spans) != 2 {
+		t.Fatalf("spans %v, expect 2", len(spans))
+	}
+	for i := 1; i < len(spans); i++ {
+		if spans[i].start < spans[i-1].end {
+			t.Fatalf("span %v overlapped with previous", spans[i].text)
+		}
+	}
+
+	//rebuild suggestion of corrected spans
+	for _, span := range spans {
+		span.candidates = []*spellCandidate{
+			{term: span.text + "x", freq: 1},
+		}
+	}
+	suggests := spell.createSuggests(key, spans, 3)
+	if len(suggests) <= 0 {
+		t.Fatal("no any suggestion")
+	}
+	if suggests[0].Key != "abcdxef ghijx" {
+		t.Fatalf("suggest key %v, expect 'abcdxef ghijx'", suggests[0].Key)
+	}
+}
@@ -74,10 +74,15 @@ func (f *Suggest) GetSuggest(
 	}
 
 	//get index
-	indexer := f.getIndex(opt.IndexTag)
-	if indexer == nil {
+	index := f.getIndex(opt.IndexTag)
+	if index == nil {
 		return nil, errors.New("invalid index tag")
 	}
+	indexer := index.AcquireIndex()
+	defer index.ReleaseIndex()
+	if indexer == nil {
+		return nil, errors.New("can't get indexer")
+	}
 	if opt.Page <= 0 {
 		opt.Page = 1
 	}
@@ -93,6 +98,18 @@ func (f *Suggest) GetSuggest(
 		docQuery = bleve.NewPrefixQuery(opt.Key)
 	case define.QueryKindOfMatchQuery:
 		docQuery = bleve.NewMatchQuery(opt.Key)
+	case define.QueryKindOfFuzzy:
+		{
+			//typo tolerant match
+			subQuery := bleve.NewMatchQuery(opt.Key)
+			subQuery.SetField(SuggestFieldKey)
+			if opt.Fuzziness <= 0 {
+				opt.Fuzziness = define.FuzzinessDefault
+			}
+			subQuery.SetFuzziness(opt.Fuzziness)
+			subQuery.SetPrefix(opt.PrefixLen)
+			docQuery = subQuery
+		}
 	default:
 		docQuery = bleve.NewMatchAllQuery()
 	}
@@ -127,10 +144,9 @@ func (f *Suggest) GetSuggest(
 	//set others
 	searchRequest.From = (opt.Page - 1) * opt.PageSize
 	searchRequest.Size = opt.PageSize
-	searchRequest.Explain = true
 
 	//begin search
-	searchResult, err := indexer.GetIndex().Search(searchRequest)
+	searchResult, err := indexer.Search(searchRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -148,7 +164,7 @@ func (f *Suggest) GetSuggest(
 	//format records
 	for _, hit := range searchResult.Hits {
 		//get original doc by id
-		doc, err := indexer.GetIndex().Document(hit.ID)
+		doc, err := indexer.Document(hit.ID)
 		if err != nil {
 			continue
 		}
@@ -272,14 +288,19 @@ func (f *Suggest) addSuggestProcess(
 	}
 
 	//get index
-	indexer := f.getIndex(req.indexTag)
-	if indexer == nil {
+	index := f.getIndex(req.indexTag)
+	if index == nil {
 		return errors.New("can't get indexer by tag")
 	}
+	indexer := index.AcquireIndex()
+	defer index.ReleaseIndex()
+	if indexer == nil {
+		return errors.New("can't get indexer")
+	}
 
 	//add or update doc
 	keyMd5 := f.genMd5(req.doc.Key)
-	oldRec, err := indexer.GetIndex().Document(keyMd5)
+	oldRec, err := indexer.Document(keyMd5)
 	if err != nil {
 		return err
 	}
@@ -304,7 +325,7 @@ func (f *Suggest) addSuggestProcess(
 	}
 
 	//sync into index
-	err = indexer.GetIndex().Index(keyMd5, req.doc)
+	err = indexer.Index(keyMd5, req.doc)
 	return err
 }
 
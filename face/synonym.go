@@ -0,0 +1,225 @@
+package face
+
+import (
+	"github.com/andyzhou/tinysearch/define"
+	"github.com/andyzhou/tinysearch/iface"
+	"github.com/andyzhou/tinysearch/json"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+/*
+ * face for synonym
+ * @author <AndyZhou>
+ * @mail <diudiu8848@163.com>
+ * - expand query key by synonym set of index, like '手机' and 'mobile'
+ * - equivalent and one way rules
+ * - query time only, synonym changes not need reindex
+ */
+
+//face info
+type Synonym struct {
+	Base
+}
+
+//construct
+func NewSynonym() *Synonym {
+	//self init
+	this := &Synonym{}
+	return this
+}
+
+//expand query opt by synonym set of index
+//key of opt converted into query tree, any of expanded keys matched
+//return original opt if no synonym matched
+func (f *Synonym) ExpandOpt(
+		index iface.IIndex,
+		opt *json.QueryOptJson,
+	) *json.QueryOptJson {
+	//basic check
+	if index == nil || opt == nil {
+		return opt
+	}
+	synonyms := index.GetSynonyms()
+	if synonyms == nil || len(synonyms.Rules) <= 0 {
+		return opt
+	}
+	return f.expandOpt(opt, synonyms)
+}
+
+//expand key by synonym set, original key first
+func (f *Synonym) ExpandKey(
+		key string,
+		synonyms *json.SynonymSetJson,
+	) []string {
+	//basic check
+	keys := []string{key}
+	if key == "" || synonyms == nil {
+		return keys
+	}
+	lowerKey := strings.ToLower(key)
+
+	//check rules one by one
+	for _, rule := range synonyms.Rules {
+		if rule == nil {
+			continue
+		}
+		for _, term := range rule.Terms {
+			//only original key checked, avoid chained expansion
+			if term == "" || !strings.Contains(lowerKey, strings.ToLower(term)) {
+				continue
+			}
+			termReg := f.getTermRegexp(term)
+			if !termReg.MatchString(key) {
+				continue
+			}
+
+			//get targets of term
+			targets := rule.Expands
+			if len(targets) <= 0 {
+				//equivalent rule
+				targets = make([]string, 0, len(rule.Terms))
+				for _, v := range rule.Terms {
+					if v != "" && !strings.EqualFold(v, term) {
+						targets = append(targets, v)
+					}
+				}
+			}
+
+			//replace term of current keys
+			for _, oldKey := range keys {
+				for _, target := range targets {
+					newKey := termReg.ReplaceAllLiteralString(oldKey, target)
+					if newKey == oldKey || f.hasKey(keys, newKey) {
+						continue
+					}
+					keys = append(keys, newKey)
+					if len(keys) >= define.SynonymExpandMax {
+						return keys
+					}
+				}
+			}
+		}
+	}
+	return keys
+}
+
+//////////////
+//private func
+//////////////
+
+//expand query opt
+func (f *Synonym) expandOpt(
+		opt *json.QueryOptJson,
+		synonyms *json.SynonymSetJson,
+	) *json.QueryOptJson {
+	//expand leaf nodes of query tree
+	if opt.QueryKind == define.QueryKindOfConjunctionQuery {
+		if opt.QueryTree == nil {
+			return opt
+		}
+		newOpt := *opt
+		newOpt.QueryTree = f.expandNode(opt.QueryTree, synonyms)
+		return &newOpt
+	}
+
+	//expand key
+	if !f.isKeyQuery(opt) {
+		return opt
+	}
+	keys := f.ExpandKey(opt.Key, synonyms)
+	if len(keys) <= 1 {
+		return opt
+	}
+
+	//convert into query tree, filters kept in top opt
+	node := json.NewQueryNode()
+	for _, key := range keys {
+		subOpt := *opt
+		subOpt.Key = key
+		subOpt.Filters = nil
+		node.AddShould(json.NewQueryLeafNode(&subOpt))
+	}
+	newOpt := *opt
+	newOpt.QueryKind = define.QueryKindOfConjunctionQuery
+	newOpt.QueryTree = node
+	return &newOpt
+}
+
+//expand query tree node, return new node
+func (f *Synonym) expandNode(
+		node *json.QueryNode,
+		synonyms *json.SynonymSetJson,
+	) *json.QueryNode {
+	if node == nil {
+		return nil
+	}
+	newNode := *node
+	if node.Query != nil {
+		newNode.Query = f.expandOpt(node.Query, synonyms)
+		return &newNode
+	}
+	expandNodes := func(nodes []*json.QueryNode) []*json.QueryNode {
+		result := make([]*json.QueryNode, 0, len(nodes))
+		for _, v := range nodes {
+			result = append(result, f.expandNode(v, synonyms))
+		}
+		return result
+	}
+	newNode.Must = expandNodes(node.Must)
+	newNode.Should = expandNodes(node.Should)
+	newNode.MustNot = expandNodes(node.MustNot)
+	newNode.Filter = expandNodes(node.Filter)
+	return &newNode
+}
+
+//check query kind use key or not
+func (f *Synonym) isKeyQuery(opt *json.QueryOptJson) bool {
+	if opt.Key == "" {
+		return false
+	}
+	switch opt.QueryKind {
+	case define.QueryKindOfMatchQuery, define.QueryKindOfPhrase,
+		define.QueryKindOfMatchPhraseQuery, define.QueryKindOfPrefix,
+		define.QueryKindOfFuzzy:
+		return true
+	case define.QueryKindOfMatchAll, define.QueryKindOfTerm,
+		define.QueryKindOfGeoDistance, define.QueryKindOfConjunctionQuery,
+		define.QueryKindOfQueryString, define.QueryKindOfWildcard,
+		define.QueryKindOfRegexp, define.QueryKindOfMoreLikeThis:
+		return false
+	default:
+		//match query used for key
+		return true
+	}
+}
+
+//get case insensitive regexp of term
+//word boundary used for latin term, like 'mobile' not in 'automobile'
+func (f *Synonym) getTermRegexp(term string) *regexp.Regexp {
+	runes := []rune(term)
+	pattern := regexp.QuoteMeta(term)
+	if f.isWordRune(runes[0]) {
+		pattern = `\b` + pattern
+	}
+	if f.isWordRune(runes[len(runes)-1]) {
+		pattern = pattern + `\b`
+	}
+	return regexp.MustCompile("(?i)" + pattern)
+}
+
+//check rune is latin word or not
+func (f *Synonym) isWordRune(r rune) bool {
+	return r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_')
+}
+
+//check key exists or not
+func (f *Synonym) hasKey(keys []string, key string) bool {
+	for _, v := range keys {
+		if strings.EqualFold(v, key) {
+			return true
+		}
+	}
+	return false
+}
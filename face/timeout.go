@@ -0,0 +1,117 @@
+package face
+
+import (
+	"context"
+	"github.com/andyzhou/tinysearch/define"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/query"
+	index "github.com/blevesearch/bleve_index_api"
+	"sync/atomic"
+	"time"
+)
+
+/*
+ * face for time limit query
+ * @author <AndyZhou>
+ * @mail <diudiu8848@163.com>
+ * - used for timeout of query opt
+ * - searcher treated as ended after deadline, hits collected before kept
+ * - shared by searchers of all sub indexes
+ */
+
+//inter time limit query
+type timeLimitQuery struct {
+	query.Query
+	deadline time.Time
+	timedOut int32 //atomic, 1 means deadline reached
+}
+
+//inter time limit searcher
+type timeLimitSearcher struct {
+	search.Searcher
+	parent *timeLimitQuery
+	count  int
+}
+
+//construct
+func newTimeLimitQuery(q query.Query, deadline time.Time) *timeLimitQuery {
+	this := &timeLimitQuery{
+		Query: q,
+		deadline: deadline,
+	}
+	return this
+}
+
+//check deadline reached or not
+func (q *timeLimitQuery) IsTimedOut() bool {
+	return atomic.LoadInt32(&q.timedOut) == 1
+}
+
+//validate wrapped query
+func (q *timeLimitQuery) Validate() error {
+	if vq, ok := q.Query.(query.ValidatableQuery); ok {
+		return vq.Validate()
+	}
+	return nil
+}
+
+//get searcher
+func (q *timeLimitQuery) Searcher(
+		ctx context.Context,
+		i index.IndexReader,
+		m mapping.IndexMapping,
+		options search.SearcherOptions,
+	) (search.Searcher, error) {
+	searcher, err := q.Query.Searcher(ctx, i, m, options)
+	if err != nil {
+		return nil, err
+	}
+	return &timeLimitSearcher{
+		Searcher: searcher,
+		parent: q,
+	}, nil
+}
+
+/////////////////////////////
+//api for time limit searcher
+/////////////////////////////
+
+//get next doc, nil if deadline reached
+func (s *timeLimitSearcher) Next(ctx *search.SearchContext) (*search.DocumentMatch, error) {
+	if s.isTimedOut() {
+		return nil, nil
+	}
+	return s.Searcher.Next(ctx)
+}
+
+//advance to doc, nil if deadline reached
+func (s *timeLimitSearcher) Advance(
+		ctx *search.SearchContext,
+		ID index.IndexInternalID,
+	) (*search.DocumentMatch, error) {
+	if s.isTimedOut() {
+		return nil, nil
+	}
+	return s.Searcher.Advance(ctx, ID)
+}
+
+//////////////
+//private func
+//////////////
+
+//check deadline every batch of docs
+func (s *timeLimitSearcher) isTimedOut() bool {
+	if s.parent.IsTimedOut() {
+		return true
+	}
+	s.count++
+	if s.count % define.QueryTimeoutCheckEvery != 0 {
+		return false
+	}
+	if time.Now().Before(s.parent.deadline) {
+		return false
+	}
+	atomic.StoreInt32(&s.parent.timedOut, 1)
+	return true
+}
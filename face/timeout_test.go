@@ -0,0 +1,101 @@
+package face
+
+import (
+	"context"
+	"fmt"
+	"github.com/andyzhou/tinysearch/define"
+	"github.com/andyzhou/tinysearch/json"
+	"github.com/blevesearch/bleve/v2"
+	"testing"
+	"time"
+)
+
+//test hits collected before deadline returned
+func TestTimeLimitQuery(t *testing.T) {
+	m := newTestManager(t)
+	index := newTestIndex(t, m, "posts")
+	indexer := index.AcquireIndex()
+	defer index.ReleaseIndex()
+	batch := indexer.NewBatch()
+	for i := 0; i < define.QueryTimeoutCheckEvery * 3; i++ {
+		batch.Index(fmt.Sprintf("%d", i), map[string]interface{}{"title": "apple"})
+	}
+	err := indexer.Batch(batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//deadline reached, stopped at first check
+	base := &Base{}
+	searchRequest := bleve.NewSearchRequest(bleve.NewMatchAllQuery())
+	timeLimit := base.setTimeLimit(searchRequest, 1)
+	timeLimit.deadline = time.Now().Add(-time.Second)
+	searchResult, err := indexer.Search(searchRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !timeLimit.IsTimedOut() {
+		t.Fatal("query should be timed out")
+	}
+	if searchResult.Total <= 0 || searchResult.Total >= uint64(define.QueryTimeoutCheckEvery) {
+		t.Fatalf("total %v, expect hits before first check", searchResult.Total)
+	}
+	timedOut, errs := base.getSearchErrors(index.GetTag(), searchResult.Status, timeLimit)
+	if !timedOut || errs[index.GetTag()] == "" {
+		t.Fatal("timed out index should be reported")
+	}
+
+	//no limit
+	searchRequest = bleve.NewSearchRequest(bleve.NewMatchAllQuery())
+	if base.setTimeLimit(searchRequest, 0) != nil {
+		t.Fatal("no time limit should be set without timeout")
+	}
+}
+
+//test query with timeout and cancelled context
+func TestQueryWithContext(t *testing.T) {
+	m := newTestManager(t)
+	index := newTestIndex(t, m, "posts")
+	addTestDocs(t, m, index, map[string]map[string]interface{}{
+		"1": {"title": "apple"},
+		"2": {"title": "pear"},
+	})
+	opt := json.NewQueryOptJson()
+	opt.QueryKind = define.QueryKindOfMatchAll
+	opt.Size = 10
+	opt.Timeout = 10000
+
+	//finished before timeout
+	result, err := m.GetQuery().QueryWithContext(context.Background(), index, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 2 || result.TimedOut || result.Partial {
+		t.Fatalf("total %v, timed out %v, expect full result", result.Total, result.TimedOut)
+	}
+
+	//cancelled by caller
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = m.GetQuery().QueryWithContext(ctx, index, opt)
+	if err == nil {
+		t.Fatal("query of cancelled context should be failed")
+	}
+
+	//agg finished before timeout, then cancelled
+	aggOpt := json.NewQueryOptJson()
+	aggOpt.Key = "apple"
+	aggOpt.AggFields = []*json.AggField{{Field: "title", Size: 10}}
+	aggOpt.Timeout = 10000
+	aggs, err := m.GetAgg().GetAggListWithContext(context.Background(), index, aggOpt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aggs == nil || aggs.TimedOut || aggs.Partial {
+		t.Fatal("agg should be finished before timeout")
+	}
+	_, err = m.GetAgg().GetAggListWithContext(ctx, index, aggOpt)
+	if err == nil {
+		t.Fatal("agg of cancelled context should be failed")
+	}
+}
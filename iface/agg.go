@@ -1,6 +1,9 @@
 package iface
 
-import "github.com/andyzhou/tinysearch/json"
+import (
+	"context"
+	"github.com/andyzhou/tinysearch/json"
+)
 
 /*
  * interface for agg
@@ -8,4 +11,5 @@ import "github.com/andyzhou/tinysearch/json"
 
 type IAgg interface {
 	GetAggList(index IIndex, opt *json.QueryOptJson) (*json.AggregatesJson, error)
+	GetAggListWithContext(ctx context.Context, index IIndex, opt *json.QueryOptJson) (*json.AggregatesJson, error)
 }
@@ -11,6 +11,7 @@ type IRpcClient interface {
 	DocGet(tag string, docIds ...string) ([][]byte, error)
 	DocSync(tag, docId string, jsonByte []byte) bool
 	IndexCreate(tag string) error
+	DocPercolate(tag string, cb func(jsonByte []byte)) error
 	IsActive() bool
 }
 
@@ -12,7 +12,8 @@ type IDoc interface {
 	RemoveDoc(index IIndex, docId string) error
 	GetDocs(index IIndex, docIds ...string) (map[string]*json.HitDocJson, error)
 	GetDoc(index IIndex, docId string) (*json.HitDocJson, error)
-	AddDoc(index IIndex, docId string, jsonObj interface{}) error
+	AddDoc(index IIndex, docId string, jsonObj interface{}, boosts ...float64) error
 	SetHookForAddDoc(hook func(jsonByte []byte) error) error
 	GetHoodForAddDoc() func(jsonByte []byte) error
+	SetPercolator(percolator IPercolator)
 }
\ No newline at end of file
@@ -1,8 +1,10 @@
 package iface
 
 import (
+	"github.com/andyzhou/tinysearch/json"
 	"github.com/blevesearch/bleve/v2"
 	"github.com/blevesearch/bleve/v2/mapping"
+	"time"
 )
 
 /*
@@ -10,8 +12,23 @@ import (
  */
 
 type IIndex interface {
+	GetTag() string
+	Close() error
+	Unload() error
+	IsOpened() bool
+	GetActiveTime() time.Time
+	GetSchema() map[string]string
+	MergeSchema(fields map[string]string) error
+	GetVersion() int64
+	IncVersion()
+	GetSynonyms() *json.SynonymSetJson
+	SetSynonyms(synonyms *json.SynonymSetJson) error
+	GetPercolates() *json.PercolateSetJson
+	SetPercolates(percolates *json.PercolateSetJson) error
 	RemoveIndex() error
 	GetIndex() bleve.Index
+	AcquireIndex() bleve.Index
+	ReleaseIndex()
 	CreateIndex() error
 	CreateChineseMap(dictPath string) (*mapping.IndexMappingImpl, error)
 	SetDictPath(dict string) bool
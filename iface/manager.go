@@ -1,5 +1,7 @@
 package iface
 
+import "github.com/andyzhou/tinysearch/json"
+
 /*
  * interface for inter manager
  */
@@ -7,12 +9,41 @@ package iface
 type IManager interface {
 	Quit()
 	GetDictFile() string
+	GetDataPath() string
 	SetDataPath(path string)
 	SetDictFile(filePath string)
+	SetIdleTimeout(seconds int)
+	SetMaxOpened(num int)
+	GetIndexStats() *json.IndexStatsJson
+
+	//for index template
+	AddTemplate(tpl *json.IndexTemplateJson) error
+	RemoveTemplate(name string) error
+	GetTemplates() []*json.IndexTemplateJson
+	GetTemplate(tag string) *json.IndexTemplateJson
+
+	//for rolling index
+	AddRolloverPolicy(policy *json.RolloverPolicyJson) error
+	RemoveRolloverPolicy(name string) error
+
+	//for synonym
+	SetSynonyms(tag string, synonyms *json.SynonymSetJson) error
+	GetSynonyms(tag string) (*json.SynonymSetJson, error)
+
+	//for percolate
+	AddPercolateQuery(tag, id string, opt *json.QueryOptJson) error
+	RemovePercolateQuery(tag, id string) error
+	GetPercolateQueries(tag string) ([]*json.PercolateQueryJson, error)
 
 	//for index
 	RemoveIndex(tag string) error
+	DropIndex(tag string) error
+	GetIndexTags() []string
+	GetQueryIndex(tag string) IIndex
+	GetMatchedIndexes(tag string) []IIndex
+	GetAliasIndex(pattern string) IIndex
 	GetIndex(tag string) IIndex
+	GetOrAddIndex(tag string) (IIndex, error)
 	AddIndex(tag string) error
 
 	//get sub face
@@ -20,4 +51,5 @@ type IManager interface {
 	GetQuery() IQuery
 	GetAgg() IAgg
 	GetSuggest() ISuggest
+	GetPercolator() IPercolator
 }
\ No newline at end of file
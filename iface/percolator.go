@@ -0,0 +1,18 @@
+package iface
+
+import "github.com/andyzhou/tinysearch/json"
+
+/*
+ * interface for percolator
+ */
+
+type IPercolator interface {
+	AddQuery(index IIndex, id string, opt *json.QueryOptJson) error
+	RemoveQuery(index IIndex, id string) error
+	GetQueries(index IIndex) []*json.PercolateQueryJson
+	SetCallback(cb func(match *json.PercolateMatchJson))
+	Subscribe(pattern string) (int64, <-chan *json.PercolateMatchJson)
+	Unsubscribe(id int64)
+	Percolate(index IIndex, docId string, doc map[string]interface{}) error
+	Quit()
+}
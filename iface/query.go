@@ -1,8 +1,10 @@
 package iface
 
 import (
+	"context"
 	"github.com/andyzhou/tinysearch/json"
 	"github.com/blevesearch/bleve/v2"
+	"time"
 )
 
 /*
@@ -12,5 +14,11 @@ import (
 type IQuery interface {
 	QueryAll(index IIndex, needDoc ...bool) (*json.SearchResultJson, error)
 	Query(index IIndex, json *json.QueryOptJson) (*json.SearchResultJson, error)
-	BuildSearchReq(json *json.QueryOptJson) *bleve.SearchRequest
+	QueryWithContext(ctx context.Context, index IIndex, json *json.QueryOptJson) (*json.SearchResultJson, error)
+	DeleteByQuery(index IIndex, json *json.QueryOptJson) (int64, error)
+	BuildSearchReq(json *json.QueryOptJson) (*bleve.SearchRequest, error)
+	SetHookForPinyin(hook func(word string) string)
+	SetCache(maxSize int, ttl time.Duration)
+	ClearCache(tags ...string)
+	GetCacheStats(tag string) *json.QueryCacheStatsJson
 }
\ No newline at end of file
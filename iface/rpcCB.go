@@ -29,5 +29,10 @@ type IRpcCB interface {
 		ctx context.Context,
 		in *search.DocSyncReq,
 	) (*search.DocSyncResp, error)
+
+	DocPercolate(
+		in *search.DocQueryReq,
+		stream search.SearchService_DocPercolateServer,
+	) error
 }
 
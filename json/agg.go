@@ -27,8 +27,10 @@ type AggregateJson struct {
 
 //one kind agg record
 type AggregatesJson struct {
-	Field   string                      `json:"field"`
-	MapList map[string][]*AggregateJson `json:"list"`
+	Field    string                      `json:"field"`
+	MapList  map[string][]*AggregateJson `json:"list"`
+	TimedOut bool                        `json:"timedOut,omitempty"` //timeout of query opt reached
+	Partial  bool                        `json:"partial,omitempty"`  //some indexes failed or timed out
 	BaseJson
 }
 
@@ -0,0 +1,40 @@
+package json
+
+/*
+ * json for query cache
+ * @author <AndyZhou>
+ * @mail <diudiu8848@163.com>
+ */
+
+//query cache stats json of one index
+type QueryCacheStatsJson struct {
+	Tag       string  `json:"tag"`
+	Size      int     `json:"size"`      //cached results
+	MaxSize   int     `json:"maxSize"`   //max cached results
+	TTL       int     `json:"ttl"`       //seconds
+	Hits      int64   `json:"hits"`      //hit times
+	Misses    int64   `json:"misses"`    //miss times, include expired and old version
+	Evictions int64   `json:"evictions"` //removed by size limit
+	HitRate   float64 `json:"hitRate"`   //hits / (hits + misses)
+	BaseJson
+}
+
+///////////////////////////////////
+//construct for QueryCacheStatsJson
+///////////////////////////////////
+
+func NewQueryCacheStatsJson() *QueryCacheStatsJson {
+	this := &QueryCacheStatsJson{
+	}
+	return this
+}
+
+//encode json data
+func (j *QueryCacheStatsJson) Encode() ([]byte, error) {
+	return j.BaseJson.Encode(j)
+}
+
+//decode json data
+func (j *QueryCacheStatsJson) Decode(data []byte) error {
+	return j.BaseJson.Decode(data, j)
+}
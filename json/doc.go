@@ -13,12 +13,24 @@ package json
 //	BaseJson
 //}
 
+//score explain json
+type ExplainJson struct {
+	Value    float64        `json:"value"`
+	Message  string         `json:"message"`
+	Children []*ExplainJson `json:"children,omitempty"`
+}
+
 //hit doc json
 type HitDocJson struct {
-	Id         string            `json:"id"`
-	HighLights map[string]string `json:"highLights"`
-	OrgJson    []byte            `json:"orgJson"`
-	Score      float64           `json:"score"`
+	Id            string              `json:"id"`
+	HighLights    map[string]string   `json:"highLights"`
+	HighLightList map[string][]string `json:"highLightList,omitempty"` //fragments of field, if asArray set
+	OrgJson       []byte              `json:"orgJson"`
+	Score         float64             `json:"score"`
+	Boost         float64             `json:"boost,omitempty"`       //index time boost
+	Explanation   *ExplainJson        `json:"explanation,omitempty"` //only if explain opt set
+	GroupKey      string              `json:"groupKey,omitempty"`    //collapse field value, for collapse
+	InnerHits     []*HitDocJson       `json:"innerHits,omitempty"`   //top hits of group, for collapse
 	BaseJson
 }
 
@@ -42,6 +54,18 @@ func (j *HitDocJson) AddHighLight(field, val string) bool {
 	return true
 }
 
+//add high light fragments
+func (j *HitDocJson) AddHighLightList(field string, val []string) bool {
+	if field == "" || len(val) <= 0 {
+		return false
+	}
+	if j.HighLightList == nil {
+		j.HighLightList = make(map[string][]string)
+	}
+	j.HighLightList[field] = val
+	return true
+}
+
 //encode json data
 func (j *HitDocJson) Encode() ([]byte, error) {
 	return j.BaseJson.Encode(j)
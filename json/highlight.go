@@ -0,0 +1,41 @@
+package json
+
+/*
+ * json for high light
+ * @author <AndyZhou>
+ * @mail <diudiu8848@163.com>
+ */
+
+//high light json
+type HighLightJson struct {
+	Fields       []string `json:"fields"`       //empty means all matched fields
+	FragmentSize int      `json:"fragmentSize"` //chars of one fragment, default 200
+	FragmentNum  int      `json:"fragmentNum"`  //max fragments of one field, default 1
+	Style        string   `json:"style"`        //'html' or 'ansi', default html
+	PreTag       string   `json:"preTag"`       //html default '<mark>', ansi as color code
+	PostTag      string   `json:"postTag"`      //html default '</mark>', not used for ansi
+	Separator    string   `json:"separator"`    //used for join fragments of one field
+	AsArray      bool     `json:"asArray"`      //return fragments as array, not join
+	BaseJson
+}
+
+///////////////////////////
+//construct for HighLightJson
+//////////////////////////
+
+func NewHighLightJson() *HighLightJson {
+	this := &HighLightJson{
+		Fields: []string{},
+	}
+	return this
+}
+
+//encode json data
+func (j *HighLightJson) Encode() ([]byte, error) {
+	return j.BaseJson.Encode(j)
+}
+
+//decode json data
+func (j *HighLightJson) Decode(data []byte) error {
+	return j.BaseJson.Decode(data, j)
+}
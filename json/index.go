@@ -0,0 +1,164 @@
+package json
+
+import "encoding/json"
+
+/*
+ * json for index
+ * @author <AndyZhou>
+ * @mail <diudiu8848@163.com>
+ */
+
+//index setting json
+//used for bleve index creation
+type IndexSettingJson struct {
+	IndexType string                 `json:"indexType"` //like 'scorch', empty use default
+	KVStore   string                 `json:"kvStore"`   //empty use default
+	KVConfig  map[string]interface{} `json:"kvConfig"`
+	BaseJson
+}
+
+//index template json
+//if write tag matched pattern, index will be created automatically
+type IndexTemplateJson struct {
+	Name     string            `json:"name"`
+	Pattern  string            `json:"pattern"`  //tag pattern, like 'tenant-*', 'logs-*'
+	Priority int               `json:"priority"` //bigger value, higher priority
+	Mapping  json.RawMessage   `json:"mapping"`  //bleve index mapping json
+	Analyzer string            `json:"analyzer"` //default analyzer, like 'jieba', 'standard'
+	Settings *IndexSettingJson `json:"settings"`
+	Schema   map[string]string `json:"schema"` //field path -> field type, like 'id' -> 'int'
+	BaseJson
+}
+
+//index schema json
+//field type of doc, used for format stored values
+type IndexSchemaJson struct {
+	Fields map[string]string `json:"fields"` //field path -> field type
+	BaseJson
+}
+
+//rollover policy json
+//write tag `name` will be routed to `name-date[-generation]`
+type RolloverPolicyJson struct {
+	Name       string `json:"name"`       //alias name, like 'logs'
+	DateFormat string `json:"dateFormat"` //date suffix format, default '2006.01.02'
+	MaxAge     int64  `json:"maxAge"`     //seconds, rollover if write index older than it
+	MaxDocs    int64  `json:"maxDocs"`    //rollover if write index docs reach it
+	RetainDays int    `json:"retainDays"` //drop index older than it, 0 means keep forever
+	BaseJson
+}
+
+//index stats json
+type IndexStatsJson struct {
+	Indexes     int   `json:"indexes"`     //total indexes
+	Opened      int   `json:"opened"`      //current opened indexes
+	MaxOpened   int   `json:"maxOpened"`   //open index budget, 0 means no limit
+	IdleTimeout int   `json:"idleTimeout"` //seconds, 0 means never unload
+	Opens       int64 `json:"opens"`       //total open times, include reopen
+	Evictions   int64 `json:"evictions"`   //total unload times
+	BaseJson
+}
+
+///////////////////////////
+//construct for IndexSettingJson
+//////////////////////////
+
+func NewIndexSettingJson() *IndexSettingJson {
+	this := &IndexSettingJson{
+		KVConfig: map[string]interface{}{},
+	}
+	return this
+}
+
+//encode json data
+func (j *IndexSettingJson) Encode() ([]byte, error) {
+	return j.BaseJson.Encode(j)
+}
+
+//decode json data
+func (j *IndexSettingJson) Decode(data []byte) error {
+	return j.BaseJson.Decode(data, j)
+}
+
+///////////////////////////
+//construct for IndexTemplateJson
+//////////////////////////
+
+func NewIndexTemplateJson() *IndexTemplateJson {
+	this := &IndexTemplateJson{
+		Settings: NewIndexSettingJson(),
+		Schema: map[string]string{},
+	}
+	return this
+}
+
+//encode json data
+func (j *IndexTemplateJson) Encode() ([]byte, error) {
+	return j.BaseJson.Encode(j)
+}
+
+//decode json data
+func (j *IndexTemplateJson) Decode(data []byte) error {
+	return j.BaseJson.Decode(data, j)
+}
+
+///////////////////////////
+//construct for RolloverPolicyJson
+//////////////////////////
+
+func NewRolloverPolicyJson() *RolloverPolicyJson {
+	this := &RolloverPolicyJson{
+	}
+	return this
+}
+
+//encode json data
+func (j *RolloverPolicyJson) Encode() ([]byte, error) {
+	return j.BaseJson.Encode(j)
+}
+
+//decode json data
+func (j *RolloverPolicyJson) Decode(data []byte) error {
+	return j.BaseJson.Decode(data, j)
+}
+
+///////////////////////////
+//construct for IndexStatsJson
+//////////////////////////
+
+func NewIndexStatsJson() *IndexStatsJson {
+	this := &IndexStatsJson{
+	}
+	return this
+}
+
+//encode json data
+func (j *IndexStatsJson) Encode() ([]byte, error) {
+	return j.BaseJson.Encode(j)
+}
+
+//decode json data
+func (j *IndexStatsJson) Decode(data []byte) error {
+	return j.BaseJson.Decode(data, j)
+}
+
+///////////////////////////
+//construct for IndexSchemaJson
+//////////////////////////
+
+func NewIndexSchemaJson() *IndexSchemaJson {
+	this := &IndexSchemaJson{
+		Fields: map[string]string{},
+	}
+	return this
+}
+
+//encode json data
+func (j *IndexSchemaJson) Encode() ([]byte, error) {
+	return j.BaseJson.Encode(j)
+}
+
+//decode json data
+func (j *IndexSchemaJson) Decode(data []byte) error {
+	return j.BaseJson.Decode(data, j)
+}
@@ -0,0 +1,126 @@
+package json
+
+/*
+ * json for percolate
+ * @author <AndyZhou>
+ * @mail <diudiu8848@163.com>
+ */
+
+//saved query json
+type PercolateQueryJson struct {
+	Id       string        `json:"id"`
+	Query    *QueryOptJson `json:"query"`
+	CreateAt int64         `json:"createAt"`
+	BaseJson
+}
+
+//saved query set json, per index
+type PercolateSetJson struct {
+	Queries []*PercolateQueryJson `json:"queries"`
+	BaseJson
+}
+
+//percolate match json of new doc
+type PercolateMatchJson struct {
+	Tag      string   `json:"tag"`      //index tag
+	DocId    string   `json:"docId"`
+	QueryIds []string `json:"queryIds"` //matched saved query ids
+	BaseJson
+}
+
+////////////////////////////////////
+//construct for PercolateQueryJson
+////////////////////////////////////
+
+func NewPercolateQueryJson() *PercolateQueryJson {
+	this := &PercolateQueryJson{}
+	return this
+}
+
+//encode json data
+func (j *PercolateQueryJson) Encode() ([]byte, error) {
+	return j.BaseJson.Encode(j)
+}
+
+//decode json data
+func (j *PercolateQueryJson) Decode(data []byte) error {
+	return j.BaseJson.Decode(data, j)
+}
+
+//////////////////////////////////
+//construct for PercolateSetJson
+//////////////////////////////////
+
+func NewPercolateSetJson() *PercolateSetJson {
+	this := &PercolateSetJson{
+		Queries: []*PercolateQueryJson{},
+	}
+	return this
+}
+
+//get saved query by id
+func (j *PercolateSetJson) GetQuery(id string) *PercolateQueryJson {
+	for _, v := range j.Queries {
+		if v.Id == id {
+			return v
+		}
+	}
+	return nil
+}
+
+//add or replace saved query
+func (j *PercolateSetJson) AddQuery(query *PercolateQueryJson) bool {
+	if query == nil || query.Id == "" {
+		return false
+	}
+	for idx, v := range j.Queries {
+		if v.Id == query.Id {
+			j.Queries[idx] = query
+			return true
+		}
+	}
+	j.Queries = append(j.Queries, query)
+	return true
+}
+
+//remove saved query by id
+func (j *PercolateSetJson) RemoveQuery(id string) bool {
+	for idx, v := range j.Queries {
+		if v.Id == id {
+			j.Queries = append(j.Queries[:idx], j.Queries[idx+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+//encode json data
+func (j *PercolateSetJson) Encode() ([]byte, error) {
+	return j.BaseJson.Encode(j)
+}
+
+//decode json data
+func (j *PercolateSetJson) Decode(data []byte) error {
+	return j.BaseJson.Decode(data, j)
+}
+
+////////////////////////////////////
+//construct for PercolateMatchJson
+////////////////////////////////////
+
+func NewPercolateMatchJson() *PercolateMatchJson {
+	this := &PercolateMatchJson{
+		QueryIds: []string{},
+	}
+	return this
+}
+
+//encode json data
+func (j *PercolateMatchJson) Encode() ([]byte, error) {
+	return j.BaseJson.Encode(j)
+}
+
+//decode json data
+func (j *PercolateMatchJson) Decode(data []byte) error {
+	return j.BaseJson.Decode(data, j)
+}
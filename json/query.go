@@ -16,27 +16,58 @@ type RangeVal struct {
 	To   float64 `json:"to"`
 }
 
+//range para of filter
+//nil bound means open, like 'price >= 10', 'createAt before X'
+//date bound of number or digit string is unix seconds
+type RangePara struct {
+	Type        string      `json:"type"`        //'number', 'date' or 'term', default number
+	Min         interface{} `json:"min"`         //number, date string or term
+	Max         interface{} `json:"max"`         //number, date string or term
+	IncludeMin  bool        `json:"includeMin"`  //min <= val if true
+	IncludeMax  bool        `json:"includeMax"`  //val <= max if true
+	DateFormats []string    `json:"dateFormats"` //for date, go time layouts, RFC3339 used default
+}
+
 //filter field
 type FilterField struct {
-	Kind        int         `json:"kind"`
-	Field       string      `json:"field"`
-	Val         interface{} `json:"val"`
-	DocIds      []string    `json:"docIds"`      //used for batch doc ids match
-	MinVal      string      `json:"minVal"`      //for term range
-	MaxVal      string      `json:"maxVal"`      //for term range
-	MinFloatVal float64     `json:"minFloatVal"` //for numeric range
-	MaxFloatVal float64     `json:"maxFloatVal"` //for numeric range
-	StartTime   time.Time   `json:"startTime"`   //for date range
-	EndTime     time.Time   `json:"endTime"`     //for date range
-	Terms       []string    `json:"terms"`       //for terms query
-	IsMust      bool        `json:"isMust"`
-	IsExclude   bool        `json:"isExclude"`
+	Kind           int           `json:"kind"`
+	Field          string        `json:"field"`
+	Fields         []string      `json:"fields"`         //multi fields, support boost like 'title^3'
+	MultiFieldKind int           `json:"multiFieldKind"` //for multi fields, best or most fields
+	TieBreaker     float64       `json:"tieBreaker"`     //for best fields, weight of other matched fields, 0~1
+	Val            interface{}   `json:"val"`
+	DocIds         []string      `json:"docIds"`      //used for batch doc ids match
+	MinVal         string        `json:"minVal"`      //for term range
+	MaxVal         string        `json:"maxVal"`      //for term range
+	MinFloatVal    float64       `json:"minFloatVal"` //for numeric range
+	MaxFloatVal    float64       `json:"maxFloatVal"` //for numeric range
+	StartTime      time.Time     `json:"startTime"`   //for date range
+	EndTime        time.Time     `json:"endTime"`     //for date range
+	Terms          []string      `json:"terms"`       //for terms query
+	Fuzziness      int           `json:"fuzziness"`   //for fuzzy, edit distance, default 1
+	PrefixLen      int           `json:"prefixLen"`   //for fuzzy, leading chars must exactly matched
+	Points         []interface{} `json:"points"`      //for geo, center, box corners or polygon vertexes
+	Distance       string        `json:"distance"`    //for geo distance, like '1km'
+	Range          *RangePara    `json:"range"`       //for range kind
+	IsMust         bool          `json:"isMust"`
+	IsExclude      bool          `json:"isExclude"`
+}
+
+//geo distance sort para
+type GeoDistanceSort struct {
+	Lon  float64 `json:"lon"`
+	Lat  float64 `json:"lat"`
+	Unit string  `json:"unit"` //like 'm', 'km', 'mi', default 'm'
 }
 
 //sort field
 type SortField struct {
-	Field string `json:"field"`
-	Desc  bool   `json:"desc"` //true:desc false:asc
+	Field       string           `json:"field"`       //support '_score' and '_id'
+	Desc        bool             `json:"desc"`        //true:desc false:asc
+	Type        string           `json:"type"`        //value type, 'string', 'number', 'date', default auto
+	Mode        string           `json:"mode"`        //for multi values, 'min', 'max', default auto
+	Missing     string           `json:"missing"`     //'first' or 'last', default last
+	GeoDistance *GeoDistanceSort `json:"geoDistance"` //sort by distance from point
 }
 
 //term query para
@@ -45,27 +76,80 @@ type TermQueryPara struct {
 	Val   string `json:"val"`
 }
 
+//more like this para
+//top tf-idf terms of source doc or text used for similar docs
+type MoreLikeThisPara struct {
+	DocId          string   `json:"docId"`          //source doc, excluded from result
+	Text           string   `json:"text"`           //source text if no doc id, key used if empty
+	Fields         []string `json:"fields"`         //term fields, default all text fields of doc or default field
+	MaxTerms       int      `json:"maxTerms"`       //max selected terms, default 25
+	MinTermFreq    int      `json:"minTermFreq"`    //min term freq in source, default 1
+	MinDocFreq     uint64   `json:"minDocFreq"`     //min doc freq in index, default 1
+	MinShouldMatch int      `json:"minShouldMatch"` //min matched terms, default 1
+}
+
+//collapse para, group hits by keyword field
+//best hit of group returned, paging across groups
+type CollapseJson struct {
+	Field     string `json:"field"`     //keyword field, like 'posterId'
+	InnerSize int    `json:"innerSize"` //top hits of group returned, 0 means none
+	Window    int    `json:"window"`    //top hits grouped, default 1000
+}
+
+//query node, used for nested bool query tree
+//leaf node has query opt or filter field, others are bool node
+//like (A OR B) AND NOT (C AND D)
+type QueryNode struct {
+	Must           []*QueryNode  `json:"must"`
+	Should         []*QueryNode  `json:"should"`
+	MustNot        []*QueryNode  `json:"mustNot"`
+	Filter         []*QueryNode  `json:"filter"`         //must matched, not affect score
+	MinShouldMatch int           `json:"minShouldMatch"` //min matched should clauses
+	Boost          float64       `json:"boost"`
+	Query          *QueryOptJson `json:"query"`          //leaf of query kind
+	FilterField    *FilterField  `json:"filterField"`    //leaf of filter kind, `isMust` and `isExclude` ignored
+}
+
 //json info
 type QueryOptJson struct {
-	QueryKind  int            `json:"queryKind"`
-	TermPara   TermQueryPara  `json:"termPara"`
-	Tag        string         `json:"tag"`
-	SuggestTag string         `json:"suggestTag"`
-	Key        string         `json:"key"`
-	Fields     []string       `json:"fields"`
-	Filters    []*FilterField `json:"filters"`   //sub filters
-	AggFields  []*AggField    `json:"aggFields"` //only for agg
-	Sort       []*SortField   `json:"sort"`
-	HighLight  bool           `json:"highLight"`
-	Offset     int            `json:"offset"` //first priority
-	Size       int            `json:"size"`
-	Page       int            `json:"page"` //second priority
-	PageSize   int            `json:"pageSize"`
-	AggSize    int            `json:"aggSize"`
-	NeedDocs   bool           `json:"needDocs"`
-	Lon        float64        `json:"lon"` //geo of lon
-	Lat        float64        `json:"lat"` //geo of lat
-	Distance   string         `json:"distance"` //like '1km'
+	QueryKind      int               `json:"queryKind"`
+	TermPara       TermQueryPara     `json:"termPara"`
+	MoreLikeThis   *MoreLikeThisPara `json:"moreLikeThis"` //for more like this query kind
+	Collapse       *CollapseJson     `json:"collapse"`     //group hits by field, paging across groups
+	Tag            string            `json:"tag"`
+	SuggestTag     string            `json:"suggestTag"`
+	Key            string            `json:"key"`
+	Fields         []string          `json:"fields"`         //support boost, like 'title^3'
+	MultiFieldKind int               `json:"multiFieldKind"` //for multi fields, best or most fields
+	TieBreaker     float64           `json:"tieBreaker"`     //for best fields, weight of other matched fields, 0~1
+	Filters        []*FilterField    `json:"filters"`        //sub filters
+	QueryTree      *QueryNode        `json:"queryTree"`      //for conjunction query kind
+	AggFields      []*AggField       `json:"aggFields"`      //only for agg
+	Sort           []*SortField      `json:"sort"`
+	SearchAfter    []string          `json:"searchAfter"`  //cursor of result, for next page
+	SearchBefore   []string          `json:"searchBefore"` //prev cursor of result, for prev page
+	HighLight      bool              `json:"highLight"`
+	HighLightOpt   *HighLightJson    `json:"highLightOpt"` //high light options, used default if nil
+	SpellCheck     bool              `json:"spellCheck"`   //suggest corrected keys if no any hit
+	SpellOpt       *SpellJson        `json:"spellOpt"`     //spell options, used default if nil
+	Explain        bool              `json:"explain"`      //return score explanation of hits
+	NoCache        bool              `json:"noCache"`      //skip query cache
+	Timeout        int               `json:"timeout"`      //milliseconds, 0 means no limit, hits before timeout returned as partial
+	Offset         int               `json:"offset"`       //first priority
+	Size           int               `json:"size"`
+	Page           int               `json:"page"` //second priority
+	PageSize       int               `json:"pageSize"`
+	AggSize        int               `json:"aggSize"`
+	NeedDocs       bool              `json:"needDocs"`
+	SourceIncludes []string          `json:"sourceIncludes"` //doc field paths returned, like 'title', 'user.*'
+	SourceExcludes []string          `json:"sourceExcludes"` //doc field paths not returned
+	DisableBoost   bool              `json:"disableBoost"`   //not apply index time doc boost
+	Scoring        *ScoringJson      `json:"scoring"`        //score functions, applied on top hits
+	Lon            float64           `json:"lon"`            //geo of lon
+	Lat            float64           `json:"lat"`            //geo of lat
+	Distance       string            `json:"distance"`       //like '1km'
+	Fuzziness      int               `json:"fuzziness"`      //for fuzzy, edit distance, default 1
+	PrefixLen      int               `json:"prefixLen"`      //for fuzzy, leading chars must exactly matched
 	BaseJson
 }
 
@@ -82,6 +166,58 @@ func NewFilterField() *FilterField {
 	return this
 }
 
+///////////////////////////
+//construct for QueryNode
+//////////////////////////
+
+func NewQueryNode() *QueryNode {
+	this := &QueryNode{
+		Must: []*QueryNode{},
+		Should: []*QueryNode{},
+		MustNot: []*QueryNode{},
+		Filter: []*QueryNode{},
+	}
+	return this
+}
+
+//gen leaf node of query opt
+func NewQueryLeafNode(opt *QueryOptJson) *QueryNode {
+	this := NewQueryNode()
+	this.Query = opt
+	return this
+}
+
+//gen leaf node of filter field
+func NewFilterLeafNode(filter *FilterField) *QueryNode {
+	this := NewQueryNode()
+	this.FilterField = filter
+	return this
+}
+
+//add must nodes
+func (j *QueryNode) AddMust(nodes ...*QueryNode) *QueryNode {
+	j.Must = append(j.Must, nodes...)
+	return j
+}
+
+//add should nodes
+func (j *QueryNode) AddShould(nodes ...*QueryNode) *QueryNode {
+	j.Should = append(j.Should, nodes...)
+	return j
+}
+
+//add must not nodes
+func (j *QueryNode) AddMustNot(nodes ...*QueryNode) *QueryNode {
+	j.MustNot = append(j.MustNot, nodes...)
+	return j
+}
+
+//add filter nodes
+func (j *QueryNode) AddFilter(nodes ...*QueryNode) *QueryNode {
+	j.Filter = append(j.Filter, nodes...)
+	return j
+}
+
 ///////////////////////////
 //construct for QueryOptJson
 //////////////////////////
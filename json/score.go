@@ -0,0 +1,65 @@
+package json
+
+/*
+ * json for score
+ * @author <AndyZhou>
+ * @mail <diudiu8848@163.com>
+ */
+
+//score function
+//decay on date, geo or numeric field, like recency decay
+//number value of date decay field is unix seconds
+//field value factor, like log of num
+//weight, used with filter
+type ScoreFunction struct {
+	Kind     string     `json:"kind"`     //'gauss', 'exp', 'linear', 'fieldValue', 'weight'
+	Field    string     `json:"field"`    //stored field for decay and field value
+	Origin   string     `json:"origin"`   //for decay, date like 'now', geo like 'lat,lon', or number, unix seconds for datetime field
+	Scale    string     `json:"scale"`    //for decay, duration like '7d', distance like '2km', or number
+	Offset   string     `json:"offset"`   //for decay, no decay in offset
+	Decay    float64    `json:"decay"`    //for decay, score at scale distance, default 0.5
+	Factor   float64    `json:"factor"`   //for field value, default 1
+	Modifier string     `json:"modifier"` //for field value, like 'log', 'log1p', 'sqrt', default none
+	Missing  float64    `json:"missing"`  //for field value, used if field missing
+	Weight   float64    `json:"weight"`   //multiplied with function score, default 1
+	Filter   *QueryNode `json:"filter"`   //function applied only for matched docs
+}
+
+//scoring json
+type ScoringJson struct {
+	Functions []*ScoreFunction `json:"functions"`
+	ScoreMode string           `json:"scoreMode"` //combine functions, 'multiply', 'sum', 'avg', 'max', 'min', 'first'
+	BoostMode string           `json:"boostMode"` //combine with query score, 'multiply', 'sum', 'replace', 'avg', 'max', 'min'
+	Window    int              `json:"window"`    //top hits for rescoring, default 500
+	BaseJson
+}
+
+///////////////////////////
+//construct for ScoringJson
+//////////////////////////
+
+func NewScoringJson() *ScoringJson {
+	this := &ScoringJson{
+		Functions: []*ScoreFunction{},
+	}
+	return this
+}
+
+//add function
+func (j *ScoringJson) AddFunction(obj ...*ScoreFunction) bool {
+	if obj == nil {
+		return false
+	}
+	j.Functions = append(j.Functions, obj...)
+	return true
+}
+
+//encode json data
+func (j *ScoringJson) Encode() ([]byte, error) {
+	return j.BaseJson.Encode(j)
+}
+
+//decode json data
+func (j *ScoringJson) Decode(data []byte) error {
+	return j.BaseJson.Decode(data, j)
+}
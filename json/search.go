@@ -18,8 +18,19 @@ type SearchJson struct {
 
 //search result json
 type SearchResultJson struct {
-	Total   uint64        `json:"total"`
-	Records []*HitDocJson `json:"records"`
+	Total      uint64              `json:"total"`
+	Groups     uint64              `json:"groups,omitempty"` //total group count, for collapse
+	Records    []*HitDocJson       `json:"records"`
+	Cursor     []string            `json:"cursor,omitempty"`     //sort key of last hit, for search after
+	PrevCursor []string            `json:"prevCursor,omitempty"` //sort key of first hit, for search before
+	Took       float64             `json:"took"`                 //milliseconds
+	MaxScore   float64             `json:"maxScore"`
+	Timings    map[string]float64  `json:"timings,omitempty"`  //phase -> milliseconds
+	Spells     []*SpellSuggestJson `json:"spells,omitempty"`   //did you mean, only if no any hit
+	Cached     bool                `json:"cached,omitempty"`   //result from query cache
+	TimedOut   bool                `json:"timedOut,omitempty"` //timeout of query opt reached
+	Partial    bool                `json:"partial,omitempty"`  //some indexes failed or timed out
+	Errors     map[string]string   `json:"errors,omitempty"`   //index tag -> error, for partial result
 	BaseJson
 }
 
@@ -50,6 +61,7 @@ func (j *SearchJson) Decode(data []byte) error {
 func NewSearchResultJson() *SearchResultJson {
 	this := &SearchResultJson{
 		Records:make([]*HitDocJson, 0),
+		Timings: map[string]float64{},
 	}
 	return this
 }
@@ -0,0 +1,55 @@
+package json
+
+/*
+ * json for spell suggestion
+ * @author <AndyZhou>
+ * @mail <diudiu8848@163.com>
+ */
+
+//spell option json
+type SpellJson struct {
+	Fields    []string `json:"fields"`    //dict fields, default searched fields or '_all'
+	Size      int      `json:"size"`      //max suggestions, default 3
+	Fuzziness int      `json:"fuzziness"` //edit distance, 1 or 2, default by term length
+	MinFreq   uint64   `json:"minFreq"`   //term with less doc frequency treated as misspelled, default 1
+	BaseJson
+}
+
+//spell suggestion json, like 'did you mean'
+type SpellSuggestJson struct {
+	Key   string            `json:"key"`   //corrected key
+	Freq  uint64            `json:"freq"`  //min doc frequency of corrected terms
+	Terms map[string]string `json:"terms"` //original term -> corrected term
+}
+
+///////////////////////////
+//construct for SpellJson
+//////////////////////////
+
+func NewSpellJson() *SpellJson {
+	this := &SpellJson{
+		Fields: []string{},
+	}
+	return this
+}
+
+//encode json data
+func (j *SpellJson) Encode() ([]byte, error) {
+	return j.BaseJson.Encode(j)
+}
+
+//decode json data
+func (j *SpellJson) Decode(data []byte) error {
+	return j.BaseJson.Decode(data, j)
+}
+
+////////////////////////////////
+//construct for SpellSuggestJson
+////////////////////////////////
+
+func NewSpellSuggestJson() *SpellSuggestJson {
+	this := &SpellSuggestJson{
+		Terms: map[string]string{},
+	}
+	return this
+}
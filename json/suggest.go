@@ -11,6 +11,8 @@ type SuggestOptJson struct {
 	QueryKind int    `json:"queryKind"`
 	IndexTag  string `json:"indexTag"`
 	Key       string `json:"key"`
+	Fuzziness int    `json:"fuzziness"` //for fuzzy, edit distance, default 1
+	PrefixLen int    `json:"prefixLen"` //for fuzzy, leading chars must exactly matched
 	Page      int    `json:"page"`
 	PageSize  int    `json:"pageSize"`
 	BaseJson
@@ -0,0 +1,65 @@
+package json
+
+/*
+ * json for synonym
+ * @author <AndyZhou>
+ * @mail <diudiu8848@163.com>
+ */
+
+//synonym rule
+//equivalent if expands empty, like '手机, mobile, phone'
+//one way if expands setup, like 'iphone => 手机, mobile'
+type SynonymRule struct {
+	Terms   []string `json:"terms"`
+	Expands []string `json:"expands"`
+}
+
+//synonym set json, per index
+type SynonymSetJson struct {
+	Rules []*SynonymRule `json:"rules"`
+	BaseJson
+}
+
+///////////////////////////
+//construct for SynonymSetJson
+//////////////////////////
+
+func NewSynonymSetJson() *SynonymSetJson {
+	this := &SynonymSetJson{
+		Rules: []*SynonymRule{},
+	}
+	return this
+}
+
+//add equivalent rule
+func (j *SynonymSetJson) AddEquivalent(terms ...string) bool {
+	if len(terms) <= 1 {
+		return false
+	}
+	j.Rules = append(j.Rules, &SynonymRule{
+		Terms: terms,
+	})
+	return true
+}
+
+//add one way rule
+func (j *SynonymSetJson) AddOneWay(term string, expands ...string) bool {
+	if term == "" || len(expands) <= 0 {
+		return false
+	}
+	j.Rules = append(j.Rules, &SynonymRule{
+		Terms: []string{term},
+		Expands: expands,
+	})
+	return true
+}
+
+//encode json data
+func (j *SynonymSetJson) Encode() ([]byte, error) {
+	return j.BaseJson.Encode(j)
+}
+
+//decode json data
+func (j *SynonymSetJson) Decode(data []byte) error {
+	return j.BaseJson.Decode(data, j)
+}
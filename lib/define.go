@@ -4,4 +4,5 @@ const (
 	DefaultWorkers = 9
 	DefaultQueueSize = 1024
 	DefaultAsciiSize = 2
+	DefaultLRUSize = 1024
 )
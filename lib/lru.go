@@ -0,0 +1,151 @@
+package lib
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+/*
+ * general lru cache
+ * @author <AndyZhou>
+ * @mail <diudiu8848@163.com>
+ * - least recently used item removed if reach max size
+ * - expired item removed when get
+ */
+
+//inter item
+type lruItem struct {
+	key      string
+	value    interface{}
+	expireAt int64 //unix nano, 0 means never
+}
+
+//face info
+type LRU struct {
+	maxSize   int
+	ttl       time.Duration
+	l         *list.List
+	items     map[string]*list.Element
+	hits      int64
+	misses    int64
+	evictions int64
+	sync.Mutex
+}
+
+//construct
+//ttl <= 0 means never expired
+func NewLRU(maxSize int, ttl time.Duration) *LRU {
+	if maxSize <= 0 {
+		maxSize = DefaultLRUSize
+	}
+	//self init
+	this := &LRU{
+		maxSize: maxSize,
+		ttl: ttl,
+		l: list.New(),
+		items: make(map[string]*list.Element),
+	}
+	return this
+}
+
+//get value by key
+func (f *LRU) Get(key string) (interface{}, bool) {
+	f.Lock()
+	defer f.Unlock()
+	elem, ok := f.items[key]
+	if !ok {
+		f.misses++
+		return nil, false
+	}
+	item, _ := elem.Value.(*lruItem)
+	if item.expireAt > 0 && time.Now().UnixNano() > item.expireAt {
+		//expired
+		f.removeElement(elem)
+		f.misses++
+		return nil, false
+	}
+	f.l.MoveToFront(elem)
+	f.hits++
+	return item.value, true
+}
+
+//set value of key
+func (f *LRU) Set(key string, value interface{}) {
+	var (
+		expireAt int64
+	)
+	if f.ttl > 0 {
+		expireAt = time.Now().Add(f.ttl).UnixNano()
+	}
+	f.Lock()
+	defer f.Unlock()
+
+	//update old item
+	if elem, ok := f.items[key]; ok {
+		item, _ := elem.Value.(*lruItem)
+		item.value = value
+		item.expireAt = expireAt
+		f.l.MoveToFront(elem)
+		return
+	}
+
+	//add new item, remove oldest if full
+	f.items[key] = f.l.PushFront(&lruItem{
+		key: key,
+		value: value,
+		expireAt: expireAt,
+	})
+	for f.l.Len() > f.maxSize {
+		f.removeElement(f.l.Back())
+		f.evictions++
+	}
+}
+
+//remove value of key
+func (f *LRU) Remove(key string) {
+	f.Lock()
+	defer f.Unlock()
+	if elem, ok := f.items[key]; ok {
+		f.removeElement(elem)
+	}
+}
+
+//clear all items
+func (f *LRU) Clear() {
+	f.Lock()
+	defer f.Unlock()
+	f.l.Init()
+	f.items = make(map[string]*list.Element)
+}
+
+//get item count
+func (f *LRU) Len() int {
+	f.Lock()
+	defer f.Unlock()
+	return f.l.Len()
+}
+
+//get max size
+func (f *LRU) MaxSize() int {
+	return f.maxSize
+}
+
+//get stats
+//return hits, misses, evictions
+func (f *LRU) GetStats() (int64, int64, int64) {
+	f.Lock()
+	defer f.Unlock()
+	return f.hits, f.misses, f.evictions
+}
+
+//////////////
+//private func
+//////////////
+
+//remove element, should be locked
+func (f *LRU) removeElement(elem *list.Element) {
+	item, _ := elem.Value.(*lruItem)
+	f.l.Remove(elem)
+	delete(f.items, item.key)
+}
@@ -543,34 +543,34 @@ func init() {
 func init() { proto.RegisterFile("search.proto", fileDescriptor_453745cff914010e) }
 
 var fileDescriptor_453745cff914010e = []byte{
-	// 419 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x53, 0xc1, 0x6e, 0xd4, 0x30,
-	0x10, 0x55, 0x92, 0x36, 0x4d, 0xa7, 0xa1, 0xd0, 0xa1, 0xda, 0x46, 0x39, 0x55, 0x3e, 0xa0, 0x3d,
-	0x05, 0xa9, 0x88, 0x1e, 0x41, 0xda, 0xae, 0x54, 0x7a, 0xe0, 0xb0, 0x5e, 0x4e, 0x15, 0x97, 0xd4,
-	0x19, 0x95, 0xa5, 0xda, 0x38, 0xc4, 0xce, 0x8a, 0x7c, 0x0f, 0x3f, 0x8a, 0xe2, 0x38, 0x21, 0xcb,
-	0x06, 0x21, 0xad, 0x7a, 0xf3, 0xb3, 0x3d, 0x6f, 0xde, 0x3c, 0x3f, 0x43, 0xa8, 0x28, 0x2d, 0xc5,
-	0xb7, 0xa4, 0x28, 0xa5, 0x96, 0xe8, 0xb7, 0x88, 0xbd, 0x81, 0xd3, 0x2f, 0xab, 0xbc, 0x5e, 0x1a,
-	0x34, 0x4b, 0x15, 0xe1, 0x39, 0x1c, 0x6a, 0xf9, 0x44, 0x79, 0xe4, 0x5c, 0x3a, 0xd3, 0x63, 0xde,
-	0x02, 0xf6, 0x09, 0x60, 0x2e, 0xc5, 0xb2, 0xce, 0x05, 0xa7, 0x1f, 0xf8, 0x0a, 0x3c, 0x9d, 0x3e,
-	0xda, 0x1b, 0xcd, 0xb2, 0xa9, 0xca, 0xa4, 0xb8, 0xcb, 0x22, 0xb7, 0xad, 0x32, 0x00, 0x11, 0x0e,
-	0xbe, 0x2b, 0x99, 0x47, 0xde, 0xa5, 0x33, 0x0d, 0xb9, 0x59, 0xb3, 0x6b, 0x08, 0xe7, 0x52, 0x70,
-	0x5a, 0xcb, 0x0d, 0xfd, 0x97, 0xcb, 0xeb, 0xb9, 0xd8, 0x47, 0x38, 0xe9, 0x15, 0xa8, 0x02, 0x23,
-	0x38, 0x52, 0x95, 0x10, 0xa4, 0x94, 0x29, 0x0d, 0x78, 0x07, 0x71, 0x02, 0x3e, 0x95, 0xe5, 0x67,
-	0xf5, 0x68, 0xb5, 0x58, 0xc4, 0x16, 0x70, 0x3c, 0x97, 0xe2, 0x96, 0xf4, 0x78, 0xd7, 0x09, 0xf8,
-	0xa6, 0x91, 0xb2, 0x6d, 0x2d, 0xc2, 0x18, 0x82, 0x4a, 0xd1, 0xa2, 0xa2, 0x8a, 0xcc, 0x1c, 0x01,
-	0xef, 0x31, 0xbb, 0x37, 0xae, 0x18, 0xca, 0x7d, 0x24, 0x35, 0xdc, 0x8d, 0x27, 0xb3, 0x5a, 0x37,
-	0xdc, 0xde, 0x34, 0xe4, 0x3d, 0x66, 0xb7, 0x66, 0xde, 0x45, 0x45, 0x65, 0xdd, 0x08, 0x46, 0x38,
-	0x78, 0x5a, 0xe5, 0x99, 0x61, 0x3e, 0xe4, 0x66, 0xdd, 0x0d, 0xe1, 0xfe, 0x19, 0x62, 0xcc, 0xf0,
-	0xaf, 0xc6, 0x70, 0x4b, 0xf4, 0x0c, 0x32, 0x9d, 0x2d, 0x99, 0x0c, 0x4e, 0xef, 0xf2, 0x8c, 0x7e,
-	0xde, 0x94, 0x94, 0xea, 0xf1, 0x07, 0x65, 0x37, 0xf0, 0x72, 0xeb, 0xce, 0x3e, 0x22, 0xae, 0x7e,
-	0xb9, 0xf0, 0xa2, 0x8d, 0xe9, 0x92, 0xca, 0xcd, 0x4a, 0x10, 0xbe, 0x87, 0xa0, 0x1b, 0x0c, 0x5f,
-	0x27, 0x36, 0xde, 0x03, 0xcf, 0xe2, 0xf3, 0xdd, 0x4d, 0x55, 0xe0, 0x5b, 0xf0, 0xdb, 0x47, 0xc3,
-	0xb3, 0xc1, 0x79, 0x9b, 0x8b, 0x18, 0xff, 0xde, 0x52, 0x05, 0x5e, 0x9b, 0xe0, 0xb4, 0x89, 0xc5,
-	0x21, 0x67, 0x1f, 0xe2, 0x78, 0xd8, 0xbe, 0x8f, 0xe8, 0x15, 0x1c, 0x59, 0x88, 0xb8, 0x73, 0xfe,
-	0x8f, 0x9a, 0x0f, 0x70, 0x32, 0xb0, 0x0a, 0x27, 0xdd, 0x9d, 0x6d, 0x8f, 0xe3, 0x8b, 0xd1, 0x7d,
-	0x55, 0xcc, 0x2e, 0xe0, 0x4c, 0xc8, 0x75, 0xa2, 0x45, 0xa2, 0xfb, 0x6f, 0x7d, 0xef, 0x16, 0x0f,
-	0x0f, 0xbe, 0xf9, 0xf7, 0xef, 0x7e, 0x07, 0x00, 0x00, 0xff, 0xff, 0x58, 0xd4, 0x99, 0xde, 0x07,
-	0x04, 0x00, 0x00,
+	// 431 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x54, 0xc1, 0x6e, 0xd4, 0x30,
+	0x10, 0x55, 0x76, 0xdb, 0x34, 0x3b, 0x0d, 0x85, 0x0e, 0xd5, 0x36, 0xca, 0xa9, 0xf2, 0x01, 0xed,
+	0x29, 0xa0, 0x22, 0x7a, 0x41, 0x02, 0x69, 0xbb, 0x52, 0xe9, 0x01, 0x89, 0xf5, 0x72, 0xaa, 0xb8,
+	0xa4, 0xce, 0xa8, 0x2c, 0xa5, 0x71, 0xb0, 0x9d, 0x8a, 0xfc, 0x2d, 0x9f, 0x82, 0xe2, 0x24, 0x6e,
+	0x96, 0x06, 0x81, 0xaa, 0xde, 0xfc, 0x6c, 0xcf, 0x9b, 0x37, 0xcf, 0x4f, 0x86, 0x50, 0x53, 0xaa,
+	0xc4, 0xd7, 0xa4, 0x50, 0xd2, 0x48, 0xf4, 0x1b, 0xc4, 0x5e, 0xc0, 0xde, 0xe7, 0x75, 0x5e, 0xad,
+	0x2c, 0x9a, 0xa7, 0x9a, 0xf0, 0x00, 0xb6, 0x8d, 0xbc, 0xa6, 0x3c, 0xf2, 0x8e, 0xbc, 0xd9, 0x84,
+	0x37, 0x80, 0x7d, 0x00, 0x58, 0x48, 0xb1, 0xaa, 0x72, 0xc1, 0xe9, 0x07, 0x3e, 0x83, 0xb1, 0x49,
+	0xaf, 0xda, 0x1b, 0xf5, 0xb2, 0xae, 0xca, 0xa4, 0x38, 0xcf, 0xa2, 0x51, 0x53, 0x65, 0x01, 0x22,
+	0x6c, 0x7d, 0xd3, 0x32, 0x8f, 0xc6, 0x47, 0xde, 0x2c, 0xe4, 0x76, 0xcd, 0x4e, 0x20, 0x5c, 0x48,
+	0xc1, 0xe9, 0x46, 0xde, 0xd2, 0x3f, 0xb9, 0xc6, 0x8e, 0x8b, 0xbd, 0x87, 0x5d, 0xa7, 0x40, 0x17,
+	0x18, 0xc1, 0x8e, 0x2e, 0x85, 0x20, 0xad, 0x6d, 0x69, 0xc0, 0x3b, 0x88, 0x53, 0xf0, 0x49, 0xa9,
+	0x8f, 0xfa, 0xaa, 0xd5, 0xd2, 0x22, 0xb6, 0x84, 0xc9, 0x42, 0x8a, 0x33, 0x32, 0xc3, 0x5d, 0xa7,
+	0xe0, 0xdb, 0x46, 0xba, 0x6d, 0xdb, 0x22, 0x8c, 0x21, 0x28, 0x35, 0x2d, 0x4b, 0x2a, 0xc9, 0xce,
+	0x11, 0x70, 0x87, 0xd9, 0x05, 0x40, 0x47, 0xf9, 0x10, 0x49, 0x35, 0x77, 0xed, 0xc9, 0xbc, 0x32,
+	0x35, 0xf7, 0x78, 0x16, 0x72, 0x87, 0xd9, 0x99, 0x9d, 0x77, 0x59, 0x92, 0xaa, 0x6a, 0xc1, 0x08,
+	0x5b, 0xd7, 0xeb, 0x3c, 0xb3, 0xcc, 0xdb, 0xdc, 0xae, 0xbb, 0x21, 0x46, 0x77, 0x43, 0x0c, 0x19,
+	0xfe, 0x05, 0xc2, 0x3b, 0xa2, 0x47, 0x90, 0xe9, 0x6d, 0xc8, 0x64, 0xb0, 0x77, 0x9e, 0x67, 0xf4,
+	0xf3, 0x54, 0x51, 0x6a, 0x86, 0x1f, 0x94, 0x9d, 0xc2, 0xd3, 0x8d, 0x3b, 0x0f, 0x11, 0x71, 0xfc,
+	0x6b, 0x04, 0x4f, 0x9a, 0x98, 0xae, 0x48, 0xdd, 0xae, 0x05, 0xe1, 0x1b, 0x08, 0xba, 0xc1, 0xf0,
+	0x79, 0xd2, 0xc6, 0xbb, 0xe7, 0x59, 0x7c, 0x70, 0x7f, 0x53, 0x17, 0xf8, 0x12, 0xfc, 0xe6, 0xd1,
+	0x70, 0xbf, 0x77, 0xde, 0xe4, 0x22, 0xc6, 0x3f, 0xb7, 0x74, 0x81, 0x27, 0x30, 0x71, 0x89, 0xc5,
+	0x3e, 0xa7, 0x0b, 0x71, 0xdc, 0x6f, 0xef, 0x22, 0x7a, 0x0c, 0x3b, 0x2d, 0x44, 0xbc, 0x77, 0xfe,
+	0x97, 0x9a, 0x77, 0xb0, 0xdb, 0xb3, 0x0a, 0xa7, 0xdd, 0x9d, 0x4d, 0x8f, 0xe3, 0xc3, 0xc1, 0x7d,
+	0x5d, 0xe0, 0x5b, 0xfb, 0xd8, 0x9f, 0x48, 0x09, 0xf9, 0xbd, 0x26, 0xf8, 0x7f, 0x5f, 0x5e, 0x79,
+	0xf3, 0x43, 0xd8, 0x17, 0xf2, 0x26, 0x31, 0x22, 0x31, 0xee, 0x4f, 0xb8, 0x18, 0x15, 0x97, 0x97,
+	0xbe, 0xfd, 0x34, 0x5e, 0xff, 0x1e, 0x00, 0x02, 0x33, 0x76, 0xfd, 0x44, 0x04, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -595,6 +595,8 @@ type SearchServiceClient interface {
 	DocSync(ctx context.Context, in *DocSyncReq, opts ...grpc.CallOption) (*DocSyncResp, error)
 	// index create
 	IndexCreate(ctx context.Context, in *IndexCreateReq, opts ...grpc.CallOption) (*IndexCreateResp, error)
+	// doc percolate, stream matches of saved queries when new doc synced
+	DocPercolate(ctx context.Context, in *DocQueryReq, opts ...grpc.CallOption) (SearchService_DocPercolateClient, error)
 }
 
 type searchServiceClient struct {
@@ -650,6 +652,38 @@ func (c *searchServiceClient) IndexCreate(ctx context.Context, in *IndexCreateRe
 	return out, nil
 }
 
+func (c *searchServiceClient) DocPercolate(ctx context.Context, in *DocQueryReq, opts ...grpc.CallOption) (SearchService_DocPercolateClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_SearchService_serviceDesc.Streams[0], "/search.SearchService/DocPercolate", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &searchServiceDocPercolateClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type SearchService_DocPercolateClient interface {
+	Recv() (*DocQueryResp, error)
+	grpc.ClientStream
+}
+
+type searchServiceDocPercolateClient struct {
+	grpc.ClientStream
+}
+
+func (x *searchServiceDocPercolateClient) Recv() (*DocQueryResp, error) {
+	m := new(DocQueryResp)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // SearchServiceServer is the server API for SearchService service.
 type SearchServiceServer interface {
 	// doc query, include general query, agg, suggest, etc.
@@ -662,6 +696,8 @@ type SearchServiceServer interface {
 	DocSync(context.Context, *DocSyncReq) (*DocSyncResp, error)
 	// index create
 	IndexCreate(context.Context, *IndexCreateReq) (*IndexCreateResp, error)
+	// doc percolate, stream matches of saved queries when new doc synced
+	DocPercolate(*DocQueryReq, SearchService_DocPercolateServer) error
 }
 
 // UnimplementedSearchServiceServer can be embedded to have forward compatible implementations.
@@ -683,6 +719,9 @@ func (*UnimplementedSearchServiceServer) DocSync(ctx context.Context, req *DocSy
 func (*UnimplementedSearchServiceServer) IndexCreate(ctx context.Context, req *IndexCreateReq) (*IndexCreateResp, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method IndexCreate not implemented")
 }
+func (*UnimplementedSearchServiceServer) DocPercolate(req *DocQueryReq, srv SearchService_DocPercolateServer) error {
+	return status.Errorf(codes.Unimplemented, "method DocPercolate not implemented")
+}
 
 func RegisterSearchServiceServer(s *grpc.Server, srv SearchServiceServer) {
 	s.RegisterService(&_SearchService_serviceDesc, srv)
@@ -778,6 +817,27 @@ func _SearchService_IndexCreate_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _SearchService_DocPercolate_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DocQueryReq)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SearchServiceServer).DocPercolate(m, &searchServiceDocPercolateServer{stream})
+}
+
+type SearchService_DocPercolateServer interface {
+	Send(*DocQueryResp) error
+	grpc.ServerStream
+}
+
+type searchServiceDocPercolateServer struct {
+	grpc.ServerStream
+}
+
+func (x *searchServiceDocPercolateServer) Send(m *DocQueryResp) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _SearchService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "search.SearchService",
 	HandlerType: (*SearchServiceServer)(nil),
@@ -803,6 +863,12 @@ var _SearchService_serviceDesc = grpc.ServiceDesc{
 			Handler:    _SearchService_IndexCreate_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "DocPercolate",
+			Handler:       _SearchService_DocPercolate_Handler,
+			ServerStreams: true,
+		},
+	},
 	Metadata: "search.proto",
 }
@@ -127,6 +127,15 @@ func (f *CB) DocQuery(
 		return nil, errors.New("invalid parameter")
 	}
 
+	//synonym opt, json is synonym set
+	switch in.Kind {
+	case define.QueryOptKindOfGetSynonym, define.QueryOptKindOfSetSynonym:
+		return f.synonymDocQuery(in)
+	case define.QueryOptKindOfAddPercolate, define.QueryOptKindOfRemovePercolate,
+		define.QueryOptKindOfGetPercolate:
+		return f.percolateDocQuery(in)
+	}
+
 	//decode query opt json
 	queryOptJson := json.NewQueryOptJson()
 	err = queryOptJson.Decode(in.Json)
@@ -134,18 +143,29 @@ func (f *CB) DocQuery(
 		return nil, err
 	}
 
-	//get index
-	index := f.manager.GetIndex(in.Tag)
-	if index == nil {
-		tip = fmt.Sprintf("can't get index by tag of %s", in.Tag)
-		return nil, errors.New(tip)
-	}
-
 	//format result
 	resp := &search.DocQueryResp{
 		JsonByte: make([]byte, 0),
 	}
 
+	//delete by query on all matched indexes
+	if in.Kind == define.QueryOptKindOfDelete {
+		jsonByte, err = f.deleteDocQuery(in.Tag, queryOptJson)
+		if err != nil {
+			return nil, err
+		}
+		resp.Success = true
+		resp.JsonByte = jsonByte
+		return resp, nil
+	}
+
+	//get index, support pattern tag
+	index := f.manager.GetQueryIndex(in.Tag)
+	if index == nil {
+		tip = fmt.Sprintf("can't get index by tag of %s", in.Tag)
+		return nil, errors.New(tip)
+	}
+
 	//get key data
 	optKind := in.Kind
 
@@ -153,7 +173,7 @@ func (f *CB) DocQuery(
 	switch optKind {
 	case define.QueryOptKindOfAgg:
 		{
-			jsonByte, err = f.aggDocQuery(index, queryOptJson)
+			jsonByte, err = f.aggDocQuery(ctx, index, queryOptJson)
 		}
 	case define.QueryOptKindOfSuggest:
 		{
@@ -163,7 +183,7 @@ func (f *CB) DocQuery(
 		fallthrough
 	default:
 		{
-			jsonByte, err = f.genDocQuery(index, queryOptJson)
+			jsonByte, err = f.genDocQuery(ctx, index, queryOptJson)
 		}
 	}
 
@@ -176,6 +196,46 @@ func (f *CB) DocQuery(
 	return resp, nil
 }
 
+//doc percolate
+//matches of saved queries sent by stream, until client closed
+func (f *CB) DocPercolate(
+		in *search.DocQueryReq,
+		stream search.SearchService_DocPercolateServer,
+	) error {
+	//check input
+	if in == nil {
+		return errors.New("invalid parameter")
+	}
+
+	//subscribe matches, tag support pattern
+	percolator := f.manager.GetPercolator()
+	subId, matchChan := percolator.Subscribe(in.Tag)
+	defer percolator.Unsubscribe(subId)
+
+	//loop
+	for {
+		select {
+		case match, ok := <- matchChan:
+			if !ok {
+				return nil
+			}
+			jsonByte, err := match.Encode()
+			if err != nil {
+				return err
+			}
+			err = stream.Send(&search.DocQueryResp{
+				Success: true,
+				JsonByte: jsonByte,
+			})
+			if err != nil {
+				return err
+			}
+		case <- stream.Context().Done():
+			return nil
+		}
+	}
+}
+
 //doc get
 func (f *CB) DocGet(
 		ctx context.Context,
@@ -294,8 +354,8 @@ func (f *CB) lowLevelGetDoc(
 		return nil, errors.New("invalid parameter")
 	}
 
-	//get index
-	index := f.manager.GetIndex(in.Tag)
+	//get index, support pattern tag
+	index := f.manager.GetQueryIndex(in.Tag)
 	if index == nil {
 		tip = fmt.Sprintf("can't get index by tag of %s", in.Tag)
 		return nil, errors.New(tip)
@@ -341,21 +401,21 @@ func (f *CB) lowLevelRemoveDoc(
 		return nil, errors.New("invalid parameter")
 	}
 
-	//get index
-	index := f.manager.GetIndex(in.Tag)
-	if index == nil {
+	//get indexes, support pattern tag
+	indexes := f.manager.GetMatchedIndexes(in.Tag)
+	if len(indexes) <= 0 {
 		tip = fmt.Sprintf("can't get index by tag of %s", in.Tag)
 		return nil, errors.New(tip)
 	}
 
-	//remove from local index
-	indexer := index.GetIndex()
-	for _, docId := range in.DocId {
-		err := indexer.Delete(docId)
+	//remove from all matched local indexes
+	for _, index := range indexes {
+		err := f.removeDocs(index, in.DocId)
 		if err != nil {
-			return nil, errors.New(err.Error())
+			return nil, err
 		}
 	}
+
 	//format result
 	result := &search.DocSyncResp{
 		Success:true,
@@ -363,24 +423,40 @@ func (f *CB) lowLevelRemoveDoc(
 	return result, nil
 }
 
+//remove docs from one index
+//version changed even if removed partly
+func (f *CB) removeDocs(
+		index iface.IIndex,
+		docIds []string,
+	) error {
+	indexer := index.AcquireIndex()
+	defer index.ReleaseIndex()
+	if indexer == nil {
+		return errors.New("can't get indexer")
+	}
+	defer index.IncVersion()
+	for _, docId := range docIds {
+		err := indexer.Delete(docId)
+		if err != nil {
+			return errors.New(err.Error())
+		}
+	}
+	return nil
+}
+
 //low level add doc
 func (f *CB) lowLevelAddDoc(
 		in *search.DocSyncReq,
 	) (*search.DocSyncResp, error) {
-	var (
-		tip string
-	)
-
 	//check input value
 	if in == nil {
 		return nil, errors.New("invalid parameter")
 	}
 
-	//get index
-	index := f.manager.GetIndex(in.Tag)
-	if index == nil {
-		tip = fmt.Sprintf("can't get index by tag of %s", in.Tag)
-		return nil, errors.New(tip)
+	//get index, auto create if matched template
+	index, err := f.manager.GetOrAddIndex(in.Tag)
+	if err != nil {
+		return nil, err
 	}
 
 	//check and call add doc hook
@@ -394,16 +470,8 @@ func (f *CB) lowLevelAddDoc(
 		}
 	}
 
-	//decode json byte as general kv map
-	kvMap := make(map[string]interface{})
-	err := f.BaseJson.DecodeSimple(in.Json, kvMap)
-	if err != nil {
-		return nil, err
-	}
-
 	//add into local index
-	indexer := index.GetIndex()
-	err = indexer.Index(in.DocId, kvMap)
+	err = doc.AddDoc(index, in.DocId, in.Json)
 	if err != nil {
 		return nil, errors.New(err.Error())
 	}
@@ -425,6 +493,8 @@ func (f *CB) suggestDocQuery(
 	suggestOptJson := json.NewSuggestOptJson()
 	suggestOptJson.QueryKind = queryOptJson.QueryKind
 	suggestOptJson.Key = queryOptJson.Key
+	suggestOptJson.Fuzziness = queryOptJson.Fuzziness
+	suggestOptJson.PrefixLen = queryOptJson.PrefixLen
 	suggestOptJson.IndexTag = queryOptJson.SuggestTag
 	suggestOptJson.Page = queryOptJson.Page
 	suggestOptJson.PageSize = queryOptJson.PageSize
@@ -440,12 +510,13 @@ func (f *CB) suggestDocQuery(
 
 //agg query
 func (f *CB) aggDocQuery(
+		ctx context.Context,
 		index iface.IIndex,
 		queryOptJson *json.QueryOptJson,
 	) ([]byte, error) {
-	//agg doc
+	//agg doc, stopped if rpc deadline reached
 	agg := f.manager.GetAgg()
-	aggListJson, err := agg.GetAggList(index, queryOptJson)
+	aggListJson, err := agg.GetAggListWithContext(ctx, index, queryOptJson)
 	if err != nil {
 		return nil, err
 	}
@@ -455,14 +526,122 @@ func (f *CB) aggDocQuery(
 	return aggListJson.Encode()
 }
 
+//delete by query
+//support pattern tag, deleted on every matched index
+func (f *CB) deleteDocQuery(
+		tag string,
+		queryOptJson *json.QueryOptJson,
+	) ([]byte, error) {
+	var (
+		total int64
+	)
+	//get indexes, support pattern tag
+	indexes := f.manager.GetMatchedIndexes(tag)
+	if len(indexes) <= 0 {
+		return nil, fmt.Errorf("can't get index by tag of %s", tag)
+	}
+
+	//delete doc of matched indexes
+	query := f.manager.GetQuery()
+	for _, index := range indexes {
+		deleted, err := query.DeleteByQuery(index, queryOptJson)
+		if err != nil {
+			return nil, err
+		}
+		total += deleted
+	}
+	resultsJson := json.NewSearchResultJson()
+	resultsJson.Total = uint64(total)
+	return resultsJson.Encode()
+}
+
+//get or set synonym set of index
+func (f *CB) synonymDocQuery(
+	in *search.DocQueryReq) (*search.DocQueryResp, error) {
+	var (
+		synonyms *json.SynonymSetJson
+		err error
+	)
+	if in.Kind == define.QueryOptKindOfSetSynonym {
+		synonyms = json.NewSynonymSetJson()
+		err = synonyms.Decode(in.Json)
+		if err != nil {
+			return nil, err
+		}
+		err = f.manager.SetSynonyms(in.Tag, synonyms)
+	}else{
+		synonyms, err = f.manager.GetSynonyms(in.Tag)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	//format response
+	jsonByte, err := synonyms.Encode()
+	if err != nil {
+		return nil, err
+	}
+	resp := &search.DocQueryResp{
+		Success: true,
+		JsonByte: jsonByte,
+	}
+	return resp, nil
+}
+
+//add, remove or get saved queries of index
+//json is saved query for add and remove
+func (f *CB) percolateDocQuery(
+	in *search.DocQueryReq) (*search.DocQueryResp, error) {
+	var (
+		err error
+	)
+	switch in.Kind {
+	case define.QueryOptKindOfAddPercolate, define.QueryOptKindOfRemovePercolate:
+		{
+			query := json.NewPercolateQueryJson()
+			err = query.Decode(in.Json)
+			if err != nil {
+				return nil, err
+			}
+			if in.Kind == define.QueryOptKindOfAddPercolate {
+				err = f.manager.AddPercolateQuery(in.Tag, query.Id, query.Query)
+			}else{
+				err = f.manager.RemovePercolateQuery(in.Tag, query.Id)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	//get saved queries
+	percolates := json.NewPercolateSetJson()
+	percolates.Queries, err = f.manager.GetPercolateQueries(in.Tag)
+	if err != nil {
+		return nil, err
+	}
+
+	//format response
+	jsonByte, err := percolates.Encode()
+	if err != nil {
+		return nil, err
+	}
+	resp := &search.DocQueryResp{
+		Success: true,
+		JsonByte: jsonByte,
+	}
+	return resp, nil
+}
+
 //general query
 func (f *CB) genDocQuery(
+		ctx context.Context,
 		index iface.IIndex,
 		queryOptJson *json.QueryOptJson,
 	) ([]byte, error) {
-	//query doc
+	//query doc, stopped if rpc deadline reached
 	query := f.manager.GetQuery()
-	resultsJson, err := query.Query(index, queryOptJson)
+	resultsJson, err := query.QueryWithContext(ctx, index, queryOptJson)
 	if err != nil {
 		return nil, err
 	}
@@ -206,6 +206,22 @@ func (f *Client) DocSync(
 	return
 }
 
+//watch percolate matches of index, tag support pattern
+//stream reopened if broken, run until client quit
+func (f *Client) DocPercolate(
+		tag string,
+		cb func(jsonByte []byte),
+	) error {
+	//check
+	if cb == nil || f.client == nil {
+		return errors.New("invalid parameter")
+	}
+
+	//spawn watch process
+	go f.runPercolateProcess(tag, cb)
+	return nil
+}
+
 //check client is active or not
 func (f *Client) IsActive() bool {
 	return f.isActive
@@ -243,6 +259,75 @@ func (f *Client) runMainProcess() {
 	}
 }
 
+//run percolate watch process
+func (f *Client) runPercolateProcess(
+		tag string,
+		cb func(jsonByte []byte),
+	) {
+	var (
+		m any = nil
+	)
+	//stream closed when client quit
+	ctx, cancel := context.WithCancel(context.Background())
+	defer func() {
+		if err := recover(); err != m {
+			log.Println("RpcClient:percolateProcess panic, err:", err)
+		}
+		cancel()
+	}()
+	go func() {
+		select {
+		case <- f.closeChan:
+			cancel()
+		case <- ctx.Done():
+		}
+	}()
+
+	//loop, reopen stream after check ticker
+	for {
+		err := f.recvPercolate(ctx, tag, cb)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Println("RpcClient::runPercolateProcess stream broken, err:", err)
+		select {
+		case <- time.After(time.Second * define.ClientCheckTicker):
+		case <- ctx.Done():
+			return
+		}
+	}
+}
+
+//receive percolate matches of stream
+func (f *Client) recvPercolate(
+		ctx context.Context,
+		tag string,
+		cb func(jsonByte []byte),
+	) error {
+	//init real request
+	realReq := &search.DocQueryReq{
+		Tag:tag,
+	}
+
+	//open stream
+	f.RLock()
+	client := f.client
+	f.RUnlock()
+	stream, err := (*client).DocPercolate(ctx, realReq)
+	if err != nil {
+		return err
+	}
+
+	//receive one by one
+	for {
+		resp, subErr := stream.Recv()
+		if subErr != nil {
+			return subErr
+		}
+		cb(resp.JsonByte)
+	}
+}
+
 //doc sync into rpc server
 func (f *Client) docSyncProcess(
 		req *DocSyncReq,
@@ -4,8 +4,10 @@ import (
 	"github.com/andyzhou/tinysearch/define"
 	"github.com/andyzhou/tinysearch/face"
 	"github.com/andyzhou/tinysearch/iface"
+	"github.com/andyzhou/tinysearch/json"
 	"github.com/andyzhou/tinysearch/rpc"
 	"log"
+	"time"
 )
 
 /*
@@ -17,11 +19,17 @@ import (
 
 //service para
 type ServicePara struct {
-	DataPath string
-	RpcPort int //if setup, run as rpc service
-	DictFile string
-	DocQueueMode bool //add doc with queue mode
-	QueueWorkers int //inter worker number
+	DataPath         string
+	RpcPort          int //if setup, run as rpc service
+	DictFile         string
+	DocQueueMode     bool                       //add doc with queue mode
+	QueueWorkers     int                        //inter worker number
+	IndexTemplates   []*json.IndexTemplateJson  //auto create index by matched tag
+	RolloverPolicies []*json.RolloverPolicyJson //time based rolling index
+	IndexIdleTimeout int                        //seconds, idle index will be unloaded, 0 means never
+	MaxOpenedIndexes int                        //open index budget, 0 means no limit
+	QueryCacheSize   int                        //cached results per index, 0 means disabled
+	QueryCacheTTL    int                        //seconds, default 60
 }
 
 //face info
@@ -58,6 +66,21 @@ func NewServiceWithPara(para *ServicePara) *Service {
 	this := &Service{
 		manager: face.NewManager(para.DataPath, para.DictFile),
 	}
+	this.manager.SetIdleTimeout(para.IndexIdleTimeout)
+	this.manager.SetMaxOpened(para.MaxOpenedIndexes)
+	this.manager.GetQuery().SetCache(para.QueryCacheSize, time.Duration(para.QueryCacheTTL) * time.Second)
+	//add index templates
+	for _, tpl := range para.IndexTemplates {
+		if err := this.manager.AddTemplate(tpl); err != nil {
+			log.Printf("tinySearch.Service:AddTemplate failed, err:%v", err)
+		}
+	}
+	//add rollover policies
+	for _, policy := range para.RolloverPolicies {
+		if err := this.manager.AddRolloverPolicy(policy); err != nil {
+			log.Printf("tinySearch.Service:AddRolloverPolicy failed, err:%v", err)
+		}
+	}
 	//init rpc if rpc port > 0
 	if para.RpcPort > 0 {
 		this.rpcService = rpc.NewRpcService(
@@ -138,12 +161,105 @@ func (f *Service) SetHookForAddDoc(
 	return f.manager.GetDoc().SetHookForAddDoc(hook)
 }
 
+//set query cache limit, old caches cleared
+//max size is cached results per index, <= 0 means disabled
+func (f *Service) SetQueryCache(maxSize int, ttlSeconds int) {
+	f.manager.GetQuery().SetCache(maxSize, time.Duration(ttlSeconds) * time.Second)
+}
+
+//get query cache stats of index
+func (f *Service) GetQueryCacheStats(tag string) *json.QueryCacheStatsJson {
+	return f.manager.GetQuery().GetCacheStats(tag)
+}
+
+//set pinyin hook for spell suggestion
+//used for pinyin similar candidates, like '手鸡' -> '手机'
+func (f *Service) SetHookForPinyin(
+		hook func(word string) string,
+	) {
+	f.manager.GetQuery().SetHookForPinyin(hook)
+}
+
 //get index face
 func (f *Service) GetIndex(tag string) iface.IIndex {
 	return f.manager.GetIndex(tag)
 }
 
+//get index face for query
+//support pattern tag like 'logs-*', or rollover policy name
+func (f *Service) GetQueryIndex(tag string) iface.IIndex {
+	return f.manager.GetQueryIndex(tag)
+}
+
+//get index stats, include opened and evicted count
+func (f *Service) GetIndexStats() *json.IndexStatsJson {
+	return f.manager.GetIndexStats()
+}
+
 //add index
 func (f *Service) AddIndex(tag string) error {
 	return f.manager.AddIndex(tag)
 }
+
+//drop index, include data files
+func (f *Service) DropIndex(tag string) error {
+	return f.manager.DropIndex(tag)
+}
+
+//add or replace index template
+//if write tag matched template, index will be created automatically
+func (f *Service) AddIndexTemplate(tpl *json.IndexTemplateJson) error {
+	return f.manager.AddTemplate(tpl)
+}
+
+//remove index template
+func (f *Service) RemoveIndexTemplate(name string) error {
+	return f.manager.RemoveTemplate(name)
+}
+
+//add or replace rollover policy
+//doc write on policy name will be routed to current rolling index
+//and expired rolling index will be dropped automatically
+func (f *Service) AddRolloverPolicy(policy *json.RolloverPolicyJson) error {
+	return f.manager.AddRolloverPolicy(policy)
+}
+
+//remove rollover policy
+func (f *Service) RemoveRolloverPolicy(name string) error {
+	return f.manager.RemoveRolloverPolicy(name)
+}
+
+//set synonym set of index, replace old
+//query key expanded by it, take effect without restart or reindex
+func (f *Service) SetSynonyms(tag string, synonyms *json.SynonymSetJson) error {
+	return f.manager.SetSynonyms(tag, synonyms)
+}
+
+//get synonym set of index
+func (f *Service) GetSynonyms(tag string) (*json.SynonymSetJson, error) {
+	return f.manager.GetSynonyms(tag)
+}
+
+//add or replace saved query of index
+//new doc added into index will be checked by it
+func (f *Service) AddPercolateQuery(tag, id string, opt *json.QueryOptJson) error {
+	return f.manager.AddPercolateQuery(tag, id, opt)
+}
+
+//remove saved query of index
+func (f *Service) RemovePercolateQuery(tag, id string) error {
+	return f.manager.RemovePercolateQuery(tag, id)
+}
+
+//get saved queries of index
+func (f *Service) GetPercolateQueries(tag string) ([]*json.PercolateQueryJson, error) {
+	return f.manager.GetPercolateQueries(tag)
+}
+
+//set callback for percolate matches
+//called in doc adding, should not be blocked
+func (f *Service) SetPercolateCallback(
+		cb func(match *json.PercolateMatchJson),
+	) {
+	f.manager.GetPercolator().SetCallback(cb)
+}